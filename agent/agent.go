@@ -1,16 +1,13 @@
 package agent
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -21,10 +18,6 @@ import (
 	"github.com/SatisfactoryServerManager/SSMAgentManager/customwidgets"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/mylayout"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
-	"github.com/docker/docker/api/types"
-	dockerContainer "github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
 )
 
 var (
@@ -36,23 +29,57 @@ type Agents struct {
 }
 
 type Agent struct {
-	Name             string `json:"name"`
-	PortOffset       int    `json:"portOffset"`
-	AgentType        string `json:"type"`
-	Memory           int    `json:"memory"`
-	InstallDirectory string `json:"installDir"`
-	DataDirectory    string `json:"dataDir"`
-	Installed        bool   `json:"installed"`
-	DockerID         string `json:"dockerId"`
-	APIKey           string `json:"apikey"`
+	Name          string           `json:"name"`
+	PortOffset    int              `json:"portOffset"`
+	AgentType     string           `json:"type"`
+	Memory        int              `json:"memory"`
+	Resources     AgentResources   `json:"resources"`
+	Mounts        []AgentMount     `json:"mounts,omitempty"`
+	RestartPolicy string           `json:"restartPolicy,omitempty"`
+	Image         string           `json:"image,omitempty"`
+	ImageDigest   string           `json:"imageDigest,omitempty"`
+	Network       AgentNetwork     `json:"network"`
+	HealthCheck   AgentHealthCheck `json:"healthCheck"`
+	// Health is the last health status reported by the container
+	// healthcheck.
+	Health string `json:"health,omitempty"`
+	// Endpoint is the docker endpoint the agent is placed on, the local
+	// docker host when empty.
+	Endpoint         string     `json:"endpoint,omitempty"`
+	InstallDirectory string     `json:"installDir"`
+	DataDirectory    string     `json:"dataDir"`
+	DockerID         string     `json:"dockerId"`
+	CloudServerID    string     `json:"cloudServerId"`
+	APIKey           string     `json:"apikey"`
+	State            AgentState `json:"state"`
+	LastError        string     `json:"lastError,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	StateChangedAt   time.Time  `json:"stateChangedAt"`
 }
 
-func (a *Agent) GetAgentTabItem(deleteAgentFunc func(agentname string) func()) *container.TabItem {
-	return container.NewTabItem(a.Name, a.GetAgentTabContent(deleteAgentFunc))
+// AgentTabHandlers are the gui callbacks used by the buttons on an agent tab.
+type AgentTabHandlers struct {
+	Delete  func(agentname string) func()
+	Start   func(agentname string) func()
+	Stop    func(agentname string) func()
+	Restart func(agentname string) func()
+	// DockerSettings opens the docker resource limits and restart policy of
+	// the agent.
+	DockerSettings func(agentname string) func()
+	Update         func(agentname string, newName string, opts UpdateAgentOptions)
 }
 
-func (a *Agent) GetAgentTabContent(deleteAgentFunc func(agentname string) func()) *fyne.Container {
-	title := canvas.NewText("SSM Agent - "+a.Name, theme.ForegroundColor())
+func (a *Agent) GetAgentTabItem(handlers AgentTabHandlers) *container.TabItem {
+	return container.NewTabItem(a.Name, a.GetAgentTabContent(handlers))
+}
+
+func (a *Agent) GetAgentTabContent(handlers AgentTabHandlers) *fyne.Container {
+	titleText := "SSM Agent - " + a.Name
+	if a.Endpoint != "" {
+		titleText += " on " + GetAgentHost(a)
+	}
+
+	title := canvas.NewText(titleText, theme.ForegroundColor())
 	title.TextStyle = fyne.TextStyle{
 		Bold: true,
 	}
@@ -62,7 +89,6 @@ func (a *Agent) GetAgentTabContent(deleteAgentFunc func(agentname string) func()
 
 	AgentNameBox := widget.NewEntry()
 	AgentNameBox.Text = a.Name
-	AgentNameBox.Disable()
 
 	AgentPortBox := customwidgets.NewNumericalEntry()
 	AgentPortBox.Text = strconv.Itoa(a.PortOffset)
@@ -74,24 +100,51 @@ func (a *Agent) GetAgentTabContent(deleteAgentFunc func(agentname string) func()
 
 	AgentTypeBox.Disable()
 
-	if a.AgentType == "docker" {
-		AgentTypeBox.SetSelected("Docker")
-		AgentMemoryBox.Enable()
-	} else {
-		AgentTypeBox.SetSelected("Standalone")
+	stateText := string(a.State)
+	if a.Health != "" {
+		stateText += " (" + a.Health + ")"
+	}
+	if a.LastError != "" {
+		stateText += " - " + a.LastError
+	}
+
+	AgentStateLabel := widget.NewLabel(stateText)
+	AgentStateLabel.Wrapping = fyne.TextWrapWord
+
+	// The form callbacks run after the tabs are built, so they use copies
+	// rather than the agent, which may be a reused loop variable.
+	agentName := a.Name
+	caps := a.Capabilities()
+	AgentTypeBox.SetSelected(strings.Title(a.AgentType))
+	if !caps.Container {
 		AgentMemoryBox.Disable()
 	}
 
 	form := &widget.Form{
 		Items: []*widget.FormItem{ // we can specify items in the constructor
 			{Text: "Agent Name:", Widget: AgentNameBox},
+			{Text: "Agent Status:", Widget: AgentStateLabel},
 			{Text: "Agent Port Offset:", Widget: AgentPortBox},
 			{Text: "Agent Type:", Widget: AgentTypeBox},
 			{Text: "Agent Memory (GB):", Widget: AgentMemoryBox},
 		},
 		SubmitText: "Update",
 		OnSubmit: func() { // optional, handle form submission
-			a.PortOffset, _ = strconv.Atoi(AgentPortBox.Text)
+			opts := UpdateAgentOptions{}
+
+			portOffset, err := AgentPortBox.GetValue()
+			if err == nil {
+				opts.PortOffset = &portOffset
+			}
+
+			if caps.Container {
+				memory, err := AgentMemoryBox.GetValue()
+				if err == nil {
+					opts.Memory = &memory
+				}
+			}
+
+			handlers.Update(agentName, AgentNameBox.Text, opts)
 		},
 	}
 
@@ -104,11 +157,34 @@ func (a *Agent) GetAgentTabContent(deleteAgentFunc func(agentname string) func()
 
 	vbox.Move(fyne.NewPos(10, 10))
 
-	deleteButton := widget.NewButtonWithIcon("Delete Agent", theme.DeleteIcon(), deleteAgentFunc(a.Name))
+	startButton := widget.NewButtonWithIcon("Start", theme.MediaPlayIcon(), handlers.Start(a.Name))
+	startButton.Importance = widget.HighImportance
+	startButton.Move(fyne.NewPos(0, 300))
+
+	stopButton := widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), handlers.Stop(a.Name))
+	stopButton.Move(fyne.NewPos(120, 300))
+
+	restartButton := widget.NewButtonWithIcon("Restart", theme.MediaReplayIcon(), handlers.Restart(a.Name))
+	restartButton.Move(fyne.NewPos(240, 300))
+
+	dockerSettingsButton := widget.NewButtonWithIcon("Docker Settings", theme.SettingsIcon(), handlers.DockerSettings(a.Name))
+	dockerSettingsButton.Move(fyne.NewPos(360, 300))
+
+	if !caps.Container {
+		dockerSettingsButton.Disable()
+	}
+
+	if a.State == StateRunning {
+		startButton.Disable()
+	} else if a.State == StateStopped || a.State == StateInstalled {
+		stopButton.Disable()
+	}
+
+	deleteButton := widget.NewButtonWithIcon("Delete Agent", theme.DeleteIcon(), handlers.Delete(a.Name))
 
 	deleteButton.Importance = widget.DangerImportance
-	deleteButton.Move(fyne.NewPos(0, 300))
-	buttonBox := container.New(mylayout.NewBlankLayout(), deleteButton)
+	deleteButton.Move(fyne.NewPos(620, 300))
+	buttonBox := container.New(mylayout.NewBlankLayout(), startButton, stopButton, restartButton, dockerSettingsButton, deleteButton)
 
 	content := container.New(mylayout.NewFullWidthLayout(), vbox, buttonBox)
 	return content
@@ -122,264 +198,308 @@ func LoadAgents(prefs fyne.Preferences) {
 		panic(err)
 	}
 
-	SaveAgents(prefs)
-}
-
-func SaveAgents(prefs fyne.Preferences) {
-	b, err := json.Marshal(AllAgents)
+	err = LoadDockerEndpoints(prefs)
 	if err != nil {
-		panic(err)
+		log.Printf("Error loading docker endpoints, with error %s\r\n", err.Error())
 	}
-	prefs.SetString("agentsJson", string(b))
-}
 
-func CreateNewAgent(name string,
-	agentType string,
-	portOffset int,
-	memory int,
-	dataDirectory string,
-	prefs fyne.Preferences,
-) (*Agent, error) {
+	migrateLegacyAgentState(agentsString)
+	migrateAgents()
 
-	if prefs.String("ssmurl") == "" || prefs.String("ssmapikey") == "" {
-		return nil, errors.New("ssm url or ssm apikey is not set")
+	if !utils.IsDryRun() {
+		SaveAgents(prefs)
 	}
+}
 
-	if !prefs.Bool("testedconnection") {
-		return nil, errors.New("test connection before creating an agent")
-	}
+// PreviewChanges runs fn in dry run mode and returns the side effects it
+// would have had. The in memory inventory is restored afterwards. Dry run
+// mode is global, so callers must not run other agent actions meanwhile.
+func PreviewChanges(fn func() error) ([]string, error) {
+	savedAgents := append([]Agent{}, AllAgents.Agents...)
 
-	for _, agentObj := range AllAgents.Agents {
-		if agentObj.Name == name {
-			return nil, errors.New("agent already exists with the same name")
-		}
-	}
+	utils.SetDryRun(true)
+	defer func() {
+		utils.SetDryRun(false)
+		AllAgents.Agents = savedAgents
+	}()
 
-	agent := Agent{}
+	err := fn()
+	return utils.GetPlannedActions(), err
+}
 
-	agent.Name = name
-	agent.AgentType = strings.ToLower(agentType)
-	agent.PortOffset = portOffset
+// migrateLegacyAgentState sets the state of agents that were saved before
+// lifecycle states existed, using the old installed flag.
+func migrateLegacyAgentState(agentsString string) {
+	type legacyAgents struct {
+		Agents []struct {
+			Installed bool `json:"installed"`
+		} `json:"agents"`
+	}
 
-	if agent.AgentType == "standalone" {
+	legacy := legacyAgents{}
+	if err := json.Unmarshal([]byte(agentsString), &legacy); err != nil {
+		return
+	}
 
-		if dataDirectory == "" {
-			dataDirectory, _ = filepath.Abs("/SSM/data")
+	for idx := range AllAgents.Agents {
+		agent := &AllAgents.Agents[idx]
+		if agent.State != "" || idx >= len(legacy.Agents) {
+			continue
 		}
 
-		agent.DataDirectory = filepath.Join(dataDirectory, agent.Name)
-
-		var installBaseDirectory = ""
-
-		if runtime.GOOS == "windows" {
-			installBaseDirectory, _ = filepath.Abs("C:\\Program Files\\SSM\\Agents")
-		} else if runtime.GOOS == "linux" {
-			installBaseDirectory, _ = filepath.Abs("/opt/SSM/Agents")
+		if legacy.Agents[idx].Installed {
+			agent.State = StateInstalled
+		} else {
+			agent.State = StateFailed
+			agent.LastError = "agent was not fully created"
 		}
+	}
+}
 
-		agent.InstallDirectory = filepath.Join(installBaseDirectory, agent.Name)
+// migrateAgents fills in settings that did not exist when older agents were
+// saved.
+func migrateAgents() {
+	for idx := range AllAgents.Agents {
+		agent := &AllAgents.Agents[idx]
 
-	} else if agent.AgentType == "docker" {
-		if memory == 0 {
-			return nil, errors.New("agent memory must be greater than 0")
+		backend, err := GetAgentBackend(agent.AgentType)
+		if err != nil {
+			continue
 		}
+		backend.Migrate(agent)
+	}
+}
 
-		agent.Memory = memory * 1024 * 1024 * 1024
-
-	} else {
-		return nil, errors.New("unknown agent type")
+func GetAgent(name string) (*Agent, error) {
+	for idx := range AllAgents.Agents {
+		if AllAgents.Agents[idx].Name == name {
+			return &AllAgents.Agents[idx], nil
+		}
 	}
+	return nil, errors.New("agent was not found")
+}
 
-	type newAgent struct {
-		APIKey string `json:"apiKey"`
+func SaveAgents(prefs fyne.Preferences) {
+	if utils.SkipForDryRun("save agent inventory") {
+		return
 	}
-	resModel := newAgent{}
 
-	err := utils.SendPostRequest(prefs, "/api/v1/servers", agent, &resModel)
+	b, err := json.Marshal(AllAgents)
 	if err != nil {
-		return nil, err
+		panic(err)
 	}
-	agent.APIKey = resModel.APIKey
+	prefs.SetString("agentsJson", string(b))
+}
 
-	if agent.AgentType == "standalone" {
+// CreateAgentOptions holds the optional settings of a new agent.
+type CreateAgentOptions struct {
+	// Resources are the docker resource limits. MemoryReservation is in GB.
+	Resources AgentResources
+	// RestartPolicy is the docker restart policy, DefaultRestartPolicy when
+	// empty.
+	RestartPolicy string
+	// Image is the docker image reference, the manager default image when
+	// empty.
+	Image   string
+	Network AgentNetwork
+	// HealthCheck is the docker health check, the process check when the
+	// type is empty.
+	HealthCheck AgentHealthCheck
+	// Endpoint is the docker endpoint to place the agent on, the local docker
+	// host when empty.
+	Endpoint string
+	Install  InstallOptions
+}
 
-		if utils.CheckFileExists(agent.InstallDirectory) {
-			os.RemoveAll(agent.InstallDirectory)
-		}
+func CreateNewAgent(name string,
+	agentType string,
+	portOffset int,
+	memory int,
+	dataDirectory string,
+	opts CreateAgentOptions,
+	prefs fyne.Preferences,
+) (*Agent, error) {
 
-		if utils.CheckFileExists(agent.DataDirectory) {
-			os.RemoveAll(agent.DataDirectory)
-		}
+	if utils.GetSSMURL(prefs) == "" || prefs.String("ssmapikey") == "" {
+		return nil, errors.New("ssm url or ssm apikey is not set")
+	}
 
-		utils.CreateFolder(agent.InstallDirectory)
-		utils.CreateFolder(agent.DataDirectory)
+	if !prefs.Bool("testedconnection") {
+		return nil, errors.New("test connection before creating an agent")
+	}
 
-		err := DownloadAgent(prefs, &agent)
-		if err != nil {
-			return nil, err
-		}
+	agent, backend, err := newAgentRecord(name, agentType, portOffset, memory, dataDirectory, opts, prefs)
+	if err != nil {
+		return nil, err
+	}
 
-		if runtime.GOOS == "linux" {
-			err := CreateLinuxAgentServiceFile(prefs, &agent)
-			if err != nil {
-				return nil, err
-			}
-		}
+	agent.State = StateProvisioning
+	agent.CreatedAt = time.Now().UTC()
+	agent.StateChangedAt = agent.CreatedAt
 
-	} else if agent.AgentType == "docker" {
+	AllAgents.Agents = append(AllAgents.Agents, agent)
+	SaveAgents(prefs)
 
-		err := PullDockerImage()
-		if err != nil {
-			return nil, err
-		}
+	newAgent := &AllAgents.Agents[len(AllAgents.Agents)-1]
 
-		err = CreateDockerContainer(prefs, &agent)
-		if err != nil {
+	err = installAgent(prefs, backend, newAgent, opts.Install)
+	if err != nil {
+		// Keep the record when something could not be rolled back so the
+		// leftovers can be seen and cleaned up with delete.
+		var txErr *TransactionError
+		if errors.As(err, &txErr) && txErr.RolledBackCleanly() {
+			AllAgents.Agents = RemoveAgentFromArray(AllAgents.Agents, len(AllAgents.Agents)-1)
+			SaveAgents(prefs)
 			return nil, err
 		}
 
+		newAgent.SetFailed(prefs, err)
+		return nil, err
 	}
 
-	agent.Installed = true
-
-	AllAgents.Agents = append(AllAgents.Agents, agent)
-	SaveAgents(prefs)
+	err = newAgent.SetState(prefs, StateInstalled)
+	if err != nil {
+		return nil, err
+	}
 
-	return &agent, nil
+	return newAgent, nil
 
 }
 
-func DeleteAgent(AgentName string, prefs fyne.Preferences) error {
-	index := -1
-	var agent *Agent
-	for idx := range AllAgents.Agents {
-		agent = &AllAgents.Agents[idx]
-		if AgentName == agent.Name {
-			index = idx
-			break
-		}
-	}
+// newAgentRecord validates the settings of a new agent and returns the record
+// configured by its backend, without saving or installing anything.
+func newAgentRecord(name string,
+	agentType string,
+	portOffset int,
+	memory int,
+	dataDirectory string,
+	opts CreateAgentOptions,
+	prefs fyne.Preferences,
+) (Agent, AgentBackend, error) {
 
-	if agent == nil {
-		return errors.New("agent was not found")
+	err := validateAgentName(name)
+	if err != nil {
+		return Agent{}, nil, err
 	}
 
-	if agent.AgentType == "docker" {
-		err := DeleteDockerContainer(prefs, agent)
-		if err != nil {
-			return err
-		}
-	} else {
-		err := os.RemoveAll(agent.InstallDirectory)
-		if err != nil {
-			return err
-		}
-
-		err = os.RemoveAll(agent.DataDirectory)
-		if err != nil {
-			return err
+	for _, agentObj := range AllAgents.Agents {
+		if agentObj.Name == name {
+			return Agent{}, nil, errors.New("agent already exists with the same name")
 		}
 	}
 
-	if index > -1 {
-		fmt.Printf("Deleting Agent %s\r\n", agent.Name)
-		AllAgents.Agents = RemoveAgentFromArray(AllAgents.Agents, index)
+	agent := Agent{}
 
-		SaveAgents(prefs)
+	agent.Name = name
+	agent.AgentType = strings.ToLower(agentType)
+	agent.PortOffset = portOffset
+
+	if memory > 0 {
+		agent.Memory = memory * 1024 * 1024 * 1024
 	}
-	return nil
-}
+	agent.Resources = opts.Resources
+	agent.Resources.MemoryReservation = opts.Resources.MemoryReservation * 1024 * 1024 * 1024
+	agent.RestartPolicy = opts.RestartPolicy
+	agent.Image = opts.Image
+	agent.Network = opts.Network
+	agent.HealthCheck = opts.HealthCheck
+	agent.Endpoint = opts.Endpoint
+	if agent.Image == "" {
+		agent.Image = GetDefaultAgentImage(prefs)
+	}
+	agent.DataDirectory = dataDirectory
 
-func PullDockerImage() error {
-	ctx := context.Background()
-	cli, err := client.NewClientWithOpts()
+	backend, err := GetAgentBackend(agent.AgentType)
 	if err != nil {
-		return err
+		return Agent{}, nil, err
 	}
 
-	log.Println("Pulling docker image ssmagent")
-	_, err = cli.ImagePull(ctx, "docker.io/mrhid6/ssmagent:latest", types.ImagePullOptions{})
+	err = backend.Configure(&agent)
 	if err != nil {
-		return err
+		return Agent{}, nil, err
 	}
 
-	return nil
+	return agent, backend, nil
+}
 
+func installAgent(prefs fyne.Preferences, backend AgentBackend, agent *Agent, opts InstallOptions) error {
+	return runTransaction([]transactionStep{
+		{
+			Name:     "register ssm cloud server",
+			Run:      func() error { return RegisterCloudServer(prefs, agent) },
+			Rollback: func() error { return DeregisterCloudServer(prefs, agent) },
+		},
+		{
+			Name:     "install " + agent.AgentType + " agent",
+			Run:      func() error { return backend.Install(prefs, agent, opts) },
+			Rollback: func() error { return backend.Remove(prefs, agent, false) },
+		},
+	})
 }
 
-func CreateDockerContainer(prefs fyne.Preferences, agent *Agent) error {
-	log.Println("Creating SSM Agent docker container...")
+type DeleteAgentOptions struct {
+	// KeepCloud leaves the server registered in SSM Cloud.
+	KeepCloud bool
+	// CloudOnly only removes the server from SSM Cloud and leaves the local
+	// agent in place.
+	CloudOnly bool
+	// KeepData leaves the agent volumes and data directory in place.
+	KeepData bool
+}
 
-	var serverPort = 15777 + agent.PortOffset
-	var beaconPort = 15000 + agent.PortOffset
-	var port = 7777 + agent.PortOffset
+func DeleteAgent(AgentName string, opts DeleteAgentOptions, prefs fyne.Preferences) error {
+	if opts.KeepCloud && opts.CloudOnly {
+		return errors.New("keep cloud and cloud only can not be used together")
+	}
 
-	ctx := context.Background()
-	cli, err := client.NewClientWithOpts()
+	agent, err := GetAgent(AgentName)
 	if err != nil {
 		return err
 	}
 
-	var envStrings = []string{
-		"SSM_NAME=" + agent.Name,
-		"SSM_URL=" + prefs.String("ssmurl"),
-		"SSM_APIKEY=" + agent.APIKey,
+	if opts.CloudOnly {
+		fmt.Printf("Deregistering SSM Cloud server for Agent %s\r\n", AgentName)
+		return DeregisterCloudServer(prefs, agent)
 	}
 
-	var portBindings = nat.PortMap{
-		"15777/udp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: strconv.Itoa(serverPort),
-			},
-		},
-		"15000/udp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: strconv.Itoa(beaconPort),
-			},
-		},
-		"7777/udp": []nat.PortBinding{
-			{
-				HostIP:   "0.0.0.0",
-				HostPort: strconv.Itoa(port),
-			},
-		},
+	err = agent.SetState(prefs, StateDeleting)
+	if err != nil {
+		return err
 	}
 
-	var exposedPorts = nat.PortSet{
-		"15777/udp": struct{}{},
-		"15000/udp": struct{}{},
-		"7777/udp":  struct{}{},
+	backend, err := GetAgentBackend(agent.AgentType)
+	if err != nil {
+		agent.SetFailed(prefs, err)
+		return err
 	}
 
-	resp, err := cli.ContainerCreate(ctx, &dockerContainer.Config{
-		Image:        "mrhid6/ssmagent:latest",
-		Tty:          true,
-		Env:          envStrings,
-		ExposedPorts: exposedPorts,
-	}, &dockerContainer.HostConfig{
-		PortBindings: portBindings,
-	}, nil, nil, agent.Name)
-
+	err = backend.Remove(prefs, agent, opts.KeepData)
 	if err != nil {
+		agent.SetFailed(prefs, err)
 		return err
 	}
-	agent.DockerID = resp.ID
 
-	log.Println("SSM Agent Docker container created successfully")
-	return nil
-}
+	if !opts.KeepCloud && agent.CloudServerID != "" {
+		err = DeregisterCloudServer(prefs, agent)
+		if err != nil {
+			err = fmt.Errorf("agent %s was removed locally but the ssm cloud server could not be deregistered, retry with cloud only: %w", AgentName, err)
+			agent.SetFailed(prefs, err)
+			return err
+		}
+	} else if !opts.KeepCloud {
+		log.Printf("Agent %s has no ssm cloud server id, remove the server from SSM Cloud manually\r\n", AgentName)
+	}
 
-func DeleteDockerContainer(prefs fyne.Preferences, agent *Agent) error {
+	for idx := range AllAgents.Agents {
+		if AllAgents.Agents[idx].Name == AgentName {
+			fmt.Printf("Deleting Agent %s\r\n", AgentName)
+			AllAgents.Agents = RemoveAgentFromArray(AllAgents.Agents, idx)
 
-	ctx := context.Background()
-	cli, err := client.NewClientWithOpts()
-	if err != nil {
-		return err
+			SaveAgents(prefs)
+			break
+		}
 	}
-
-	return cli.ContainerRemove(ctx, agent.DockerID, types.ContainerRemoveOptions{RemoveVolumes: true})
+	return nil
 }
 
 func RemoveAgentFromArray(a []Agent, i int) []Agent {
@@ -390,85 +510,3 @@ func RemoveAgentFromArray(a []Agent, i int) []Agent {
 
 	return a
 }
-
-func DownloadAgent(prefs fyne.Preferences, agent *Agent) error {
-	type gitRelease struct {
-		TagVersion string `json:"tag_name"`
-	}
-
-	resModel := gitRelease{}
-	err := utils.SendGetRequestURL(
-		prefs,
-		"https://api.github.com/repos/SatisfactoryServerManager/SSMAgent/releases/latest",
-		&resModel,
-	)
-
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("Latest Agent Version: %s\r\n", resModel.TagVersion)
-
-	downloadUrl := fmt.Sprintf("https://github.com/SatisfactoryServerManager/SSMAgent/releases/download/%s/", resModel.TagVersion)
-	osFile := ""
-	if runtime.GOOS == "linux" {
-		osFile = "SSMAgent-Linux-amd64.zip"
-	} else if runtime.GOOS == "windows" {
-		osFile = "SSMAgent-Windows-x64.zip"
-	} else {
-		return errors.New("unknown os")
-	}
-	downloadUrl += osFile
-
-	err = utils.DownloadFile(prefs, downloadUrl, filepath.Join(agent.InstallDirectory, "SSMAgent.zip"))
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func CreateLinuxAgentServiceFile(prefs fyne.Preferences, agent *Agent) error {
-	if runtime.GOOS != "linux" {
-		return errors.New("can only create service file on linux")
-	}
-
-	serviceContent := fmt.Sprintf(`
-[Unit]
-Description=SSM Agent Daemon - %s
-After=network.target
-
-[Service]
-User=ssm
-Group=ssm
-
-Type=simple
-WorkingDirectory=%s
-ExecStart=%s/SSMAgent -name=%s -p=%d -url=%s -apikey=%s -datadir="%s"
-TimeoutStopSec=20
-KillMode=process
-Restart=on-failure
-
-[Install]
-WantedBy=multi-user.target
-	`,
-		agent.Name,
-		agent.InstallDirectory,
-		agent.InstallDirectory,
-		agent.Name,
-		agent.PortOffset,
-		prefs.String("ssmurl"),
-		agent.APIKey,
-		agent.DataDirectory,
-	)
-
-	rawServiceFile := []byte(serviceContent)
-
-	serviceFilePath := filepath.Join("/etc/systemd/system", "SSMAgent@"+agent.Name+".service")
-
-	err := os.WriteFile(serviceFilePath, rawServiceFile, 0777)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
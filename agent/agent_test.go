@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newFakeCloud serves the ssm cloud server endpoints used when creating and
+// deleting agents, recording the requests it was sent.
+func newFakeCloud(t *testing.T, deleteStatus int) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		if r.Method == http.MethodDelete && deleteStatus != http.StatusOK {
+			w.WriteHeader(deleteStatus)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    map[string]string{"serverId": "srv1", "apiKey": "AGT-API-1"},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestCreateNewAgentRollback(t *testing.T) {
+	tests := []struct {
+		name         string
+		installErr   error
+		deleteStatus int
+		wantRequests []string
+		wantRecord   bool
+		wantState    AgentState
+	}{
+		{
+			name:         "installed agent is kept",
+			deleteStatus: http.StatusOK,
+			wantRequests: []string{"POST /api/v1/servers"},
+			wantRecord:   true,
+			wantState:    StateInstalled,
+		},
+		{
+			name:         "failed install deregisters and drops the record",
+			installErr:   errors.New("install failed"),
+			deleteStatus: http.StatusOK,
+			wantRequests: []string{"POST /api/v1/servers", "DELETE /api/v1/servers/srv1"},
+		},
+		{
+			name:         "failed rollback keeps the record",
+			installErr:   errors.New("install failed"),
+			deleteStatus: http.StatusInternalServerError,
+			wantRequests: []string{"POST /api/v1/servers", "DELETE /api/v1/servers/srv1"},
+			wantRecord:   true,
+			wantState:    StateFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{installErr: tt.installErr}
+			_, prefs := useFakeBackend(t, backend, StateInstalled)
+			AllAgents = Agents{}
+
+			server, requests := newFakeCloud(t, tt.deleteStatus)
+			prefs.SetString("ssmurl", server.URL)
+			prefs.SetString("ssmapikey", "API-1")
+			prefs.SetBool("testedconnection", true)
+
+			_, err := CreateNewAgent("agent1", "fake", 0, 0, "", CreateAgentOptions{}, prefs)
+			if !errors.Is(err, tt.installErr) {
+				t.Fatalf("CreateNewAgent() error = %v, want %v", err, tt.installErr)
+			}
+
+			if !reflect.DeepEqual(*requests, tt.wantRequests) {
+				t.Errorf("cloud requests = %v, want %v", *requests, tt.wantRequests)
+			}
+
+			agent, err := GetAgent("agent1")
+			if (err == nil) != tt.wantRecord {
+				t.Fatalf("agent record kept = %v, want %v", err == nil, tt.wantRecord)
+			}
+			if tt.wantRecord && agent.State != tt.wantState {
+				t.Errorf("state = %s, want %s", agent.State, tt.wantState)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// AgentBackend runs agents of a single agent type. Backends are looked up
+// from the registry using Agent.AgentType.
+type AgentBackend interface {
+	// Capabilities returns the optional agent settings the backend supports.
+	Capabilities() BackendCapabilities
+	// Migrate fills in settings that did not exist when older agents were
+	// saved.
+	Migrate(agent *Agent)
+	// Location describes where the agent is installed, for listings.
+	Location(agent *Agent) string
+	// Configure validates the agent settings and fills in defaults before
+	// anything is installed.
+	Configure(agent *Agent) error
+	Install(prefs fyne.Preferences, agent *Agent, opts InstallOptions) error
+	Start(agent *Agent) error
+	Stop(agent *Agent, timeout int) error
+	Status(agent *Agent) (AgentStatus, error)
+	Logs(agent *Agent, opts LogOptions, stdout io.Writer, stderr io.Writer) error
+	// Upgrade moves the installed agent to a new release.
+	Upgrade(prefs fyne.Preferences, agent *Agent, opts UpgradeOptions) error
+	// PlanUpdate validates changed agent settings and returns the reasons
+	// applying them is unsafe. previous holds the installed settings.
+	PlanUpdate(previous Agent, agent *Agent) ([]string, error)
+	// Update applies changed agent settings to the installed agent.
+	Update(prefs fyne.Preferences, previous Agent, agent *Agent) error
+	// Rename moves the installed agent from the previous name to
+	// agent.Name, updating any paths stored on agent.
+	Rename(prefs fyne.Preferences, previous Agent, agent *Agent) error
+	// Remove uninstalls the agent, keeping its data when keepData is set.
+	Remove(prefs fyne.Preferences, agent *Agent, keepData bool) error
+	// Discover finds agents of this type that are installed on the host,
+	// whether or not they are in the inventory.
+	Discover() ([]Agent, error)
+	// Diagnose compares the agent record with what is actually installed.
+	Diagnose(prefs fyne.Preferences, agent *Agent) ([]DoctorIssue, error)
+}
+
+// BackendCapabilities are the optional agent settings a backend supports, so
+// callers check them instead of switching on the agent type.
+type BackendCapabilities struct {
+	// Container is set for backends that run agents in containers, with
+	// memory and resource limits, restart policies, images, networks and
+	// health checks.
+	Container bool
+	// RemoteEndpoints is set for backends that can place agents on other
+	// hosts.
+	RemoteEndpoints bool
+}
+
+// Capabilities returns the capabilities of the agent backend, or none if the
+// agent type is unknown.
+func (a *Agent) Capabilities() BackendCapabilities {
+	backend, err := GetAgentBackend(a.AgentType)
+	if err != nil {
+		return BackendCapabilities{}
+	}
+	return backend.Capabilities()
+}
+
+// GetAgentLocation describes where an agent is installed.
+func GetAgentLocation(agent *Agent) string {
+	backend, err := GetAgentBackend(agent.AgentType)
+	if err != nil {
+		return ""
+	}
+	return backend.Location(agent)
+}
+
+// AgentStatus is the live runtime state of an agent as reported by its
+// backend.
+type AgentStatus struct {
+	Running      bool      `json:"running"`
+	State        string    `json:"state"`
+	SubState     string    `json:"subState,omitempty"`
+	Health       string    `json:"health,omitempty"`
+	StartedAt    time.Time `json:"startedAt"`
+	RestartCount int       `json:"restartCount"`
+	PID          int       `json:"pid"`
+}
+
+func (s AgentStatus) Uptime() time.Duration {
+	if !s.Running || s.StartedAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.StartedAt).Truncate(time.Second)
+}
+
+// IsHealthy reports whether the live status matches the state the agent is
+// expected to be in.
+func (s AgentStatus) IsHealthy(agent *Agent) bool {
+	if agent.State == StateFailed || s.Health == "unhealthy" {
+		return false
+	}
+
+	if agent.State == StateRunning && !s.Running {
+		return false
+	}
+	return true
+}
+
+type LogOptions struct {
+	Follow bool
+	// Tail is the number of lines to show from the end of the logs, or all.
+	Tail string
+	// Since only shows logs newer than a duration, like 1h, or a timestamp.
+	Since      string
+	Timestamps bool
+}
+
+var agentBackends = map[string]AgentBackend{}
+
+func RegisterAgentBackend(agentType string, backend AgentBackend) {
+	agentBackends[agentType] = backend
+}
+
+func GetAgentBackend(agentType string) (AgentBackend, error) {
+	backend, ok := agentBackends[agentType]
+	if !ok {
+		return nil, errors.New("unknown agent type")
+	}
+	return backend, nil
+}
+
+func init() {
+	RegisterAgentBackend("docker", &DockerBackend{})
+	RegisterAgentBackend("standalone", &StandaloneBackend{})
+}
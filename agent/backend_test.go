@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+// fakeBackend is an agent backend that records calls and returns the
+// configured errors instead of touching docker or the host.
+type fakeBackend struct {
+	caps       BackendCapabilities
+	installErr error
+	startErr   error
+	stopErr    error
+	upgradeErr error
+	updateErr  error
+	// discovered and discoverErr are returned by Discover.
+	discovered  []Agent
+	discoverErr error
+	calls       []string
+}
+
+func (b *fakeBackend) Capabilities() BackendCapabilities {
+	return b.caps
+}
+
+func (b *fakeBackend) Migrate(agent *Agent) {
+	b.calls = append(b.calls, "migrate")
+}
+
+func (b *fakeBackend) Location(agent *Agent) string {
+	return "fake:" + agent.Name
+}
+
+func (b *fakeBackend) Configure(agent *Agent) error {
+	return nil
+}
+
+func (b *fakeBackend) Install(prefs fyne.Preferences, agent *Agent, opts InstallOptions) error {
+	b.calls = append(b.calls, "install")
+	return b.installErr
+}
+
+func (b *fakeBackend) Start(agent *Agent) error {
+	b.calls = append(b.calls, "start")
+	return b.startErr
+}
+
+func (b *fakeBackend) Stop(agent *Agent, timeout int) error {
+	b.calls = append(b.calls, "stop")
+	return b.stopErr
+}
+
+func (b *fakeBackend) Status(agent *Agent) (AgentStatus, error) {
+	return AgentStatus{Running: agent.State == StateRunning}, nil
+}
+
+func (b *fakeBackend) Logs(agent *Agent, opts LogOptions, stdout io.Writer, stderr io.Writer) error {
+	return nil
+}
+
+func (b *fakeBackend) Upgrade(prefs fyne.Preferences, agent *Agent, opts UpgradeOptions) error {
+	b.calls = append(b.calls, "upgrade")
+	return b.upgradeErr
+}
+
+func (b *fakeBackend) PlanUpdate(previous Agent, agent *Agent) ([]string, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) Update(prefs fyne.Preferences, previous Agent, agent *Agent) error {
+	b.calls = append(b.calls, "update")
+	return b.updateErr
+}
+
+func (b *fakeBackend) Rename(prefs fyne.Preferences, previous Agent, agent *Agent) error {
+	return nil
+}
+
+func (b *fakeBackend) Remove(prefs fyne.Preferences, agent *Agent, keepData bool) error {
+	b.calls = append(b.calls, "remove")
+	return nil
+}
+
+func (b *fakeBackend) Discover() ([]Agent, error) {
+	return b.discovered, b.discoverErr
+}
+
+func (b *fakeBackend) Diagnose(prefs fyne.Preferences, agent *Agent) ([]DoctorIssue, error) {
+	return nil, nil
+}
+
+// useFakeBackend registers backend as the fake agent type and replaces the
+// inventory with a single fake agent in the given state.
+func useFakeBackend(t *testing.T, backend *fakeBackend, state AgentState) (*Agent, fyne.Preferences) {
+	t.Helper()
+
+	RegisterAgentBackend("fake", backend)
+	t.Cleanup(func() {
+		delete(agentBackends, "fake")
+	})
+
+	previous := AllAgents
+	AllAgents = Agents{Agents: []Agent{{Name: "fake1", AgentType: "fake", State: state}}}
+	t.Cleanup(func() {
+		AllAgents = previous
+	})
+
+	return &AllAgents.Agents[0], test.NewApp().Preferences()
+}
+
+func TestStartAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     AgentState
+		startErr  error
+		wantErr   bool
+		wantState AgentState
+		wantCalls []string
+	}{
+		{name: "stopped agent starts", state: StateStopped, wantState: StateRunning, wantCalls: []string{"start"}},
+		{name: "installed agent starts", state: StateInstalled, wantState: StateRunning, wantCalls: []string{"start"}},
+		{name: "failed start", state: StateStopped, startErr: errors.New("no"), wantErr: true, wantState: StateFailed, wantCalls: []string{"start"}},
+		{name: "provisioning agent can not run", state: StateProvisioning, wantErr: true, wantState: StateProvisioning},
+		{name: "deleting agent can not run", state: StateDeleting, wantErr: true, wantState: StateDeleting},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{startErr: tt.startErr}
+			agent, prefs := useFakeBackend(t, backend, tt.state)
+
+			err := StartAgent(agent.Name, prefs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StartAgent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if agent.State != tt.wantState {
+				t.Errorf("state = %s, want %s", agent.State, tt.wantState)
+			}
+			if !reflect.DeepEqual(backend.calls, tt.wantCalls) {
+				t.Errorf("backend calls = %v, want %v", backend.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestUpgradeAgent(t *testing.T) {
+	rolledBack := &TransactionError{FailedStep: "start new", Err: errors.New("no"), RolledBack: []string{"create new"}}
+	rollbackFailed := &TransactionError{FailedStep: "start new", Err: errors.New("no"), RollbackErrors: []error{errors.New("stuck")}}
+
+	tests := []struct {
+		name       string
+		state      AgentState
+		upgradeErr error
+		wantState  AgentState
+	}{
+		{name: "upgrade keeps running state", state: StateRunning, wantState: StateRunning},
+		{name: "upgrade recovers failed agent", state: StateFailed, wantState: StateInstalled},
+		{name: "clean rollback restores state", state: StateStopped, upgradeErr: rolledBack, wantState: StateStopped},
+		{name: "failed rollback fails agent", state: StateRunning, upgradeErr: rollbackFailed, wantState: StateFailed},
+		{name: "plain error fails agent", state: StateRunning, upgradeErr: errors.New("no"), wantState: StateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{upgradeErr: tt.upgradeErr}
+			agent, prefs := useFakeBackend(t, backend, tt.state)
+
+			err := UpgradeAgent(agent.Name, UpgradeOptions{}, prefs)
+			if !errors.Is(err, tt.upgradeErr) {
+				t.Fatalf("UpgradeAgent() error = %v, want %v", err, tt.upgradeErr)
+			}
+			if agent.State != tt.wantState {
+				t.Errorf("state = %s, want %s", agent.State, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestUpdateAgent(t *testing.T) {
+	rolledBack := &TransactionError{FailedStep: "start new", Err: errors.New("no"), RolledBack: []string{"create new"}}
+
+	tests := []struct {
+		name           string
+		updateErr      error
+		wantPortOffset int
+		wantState      AgentState
+	}{
+		{name: "update is saved", wantPortOffset: 3, wantState: StateRunning},
+		{name: "clean rollback keeps record", updateErr: rolledBack, wantPortOffset: 0, wantState: StateRunning},
+		{name: "failed update keeps settings", updateErr: errors.New("no"), wantPortOffset: 0, wantState: StateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{updateErr: tt.updateErr}
+			agent, prefs := useFakeBackend(t, backend, StateRunning)
+
+			portOffset := 3
+			err := UpdateAgent(agent.Name, UpdateAgentOptions{PortOffset: &portOffset}, prefs)
+			if !errors.Is(err, tt.updateErr) {
+				t.Fatalf("UpdateAgent() error = %v, want %v", err, tt.updateErr)
+			}
+			if agent.PortOffset != tt.wantPortOffset {
+				t.Errorf("port offset = %d, want %d", agent.PortOffset, tt.wantPortOffset)
+			}
+			if agent.State != tt.wantState {
+				t.Errorf("state = %s, want %s", agent.State, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestAgentCapabilities(t *testing.T) {
+	backend := &fakeBackend{caps: BackendCapabilities{Container: true}}
+	agent, _ := useFakeBackend(t, backend, StateInstalled)
+
+	if !agent.Capabilities().Container {
+		t.Error("fake agent should have the container capability")
+	}
+	if GetAgentLocation(agent) != "fake:fake1" {
+		t.Errorf("location = %s, want fake:fake1", GetAgentLocation(agent))
+	}
+
+	unknown := &Agent{Name: "unknown", AgentType: "unknown"}
+	if unknown.Capabilities() != (BackendCapabilities{}) {
+		t.Error("unknown agent types should have no capabilities")
+	}
+	if GetContainerAgentNames()[0] != "fake1" {
+		t.Error("container agents should be listed for upgrade")
+	}
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+)
+
+// RegisterCloudServer creates the server for the agent in SSM Cloud and
+// stores the returned server id and api key on the agent.
+func RegisterCloudServer(prefs fyne.Preferences, agent *Agent) error {
+	type newAgent struct {
+		ServerID string `json:"serverId"`
+		APIKey   string `json:"apiKey"`
+	}
+	resModel := newAgent{}
+
+	err := utils.SendPostRequest(prefs, "/api/v1/servers", agent, &resModel)
+	if err != nil {
+		return err
+	}
+
+	agent.CloudServerID = resModel.ServerID
+	agent.APIKey = resModel.APIKey
+	SaveAgents(prefs)
+
+	return nil
+}
+
+// DeregisterCloudServer removes the server for the agent from SSM Cloud.
+func DeregisterCloudServer(prefs fyne.Preferences, agent *Agent) error {
+	if agent.CloudServerID == "" {
+		return errors.New("agent has no ssm cloud server id")
+	}
+
+	var resModel interface{}
+	err := utils.SendDeleteRequest(prefs, "/api/v1/servers/"+agent.CloudServerID, &resModel)
+	if err != nil {
+		return err
+	}
+
+	agent.CloudServerID = ""
+	SaveAgents(prefs)
+
+	return nil
+}
+
+// RenameCloudServer updates the name of the agent server in SSM Cloud.
+func RenameCloudServer(prefs fyne.Preferences, agent *Agent, name string) error {
+	if agent.CloudServerID == "" {
+		return errors.New("agent has no ssm cloud server id")
+	}
+
+	type renameServer struct {
+		Name string `json:"name"`
+	}
+
+	var resModel interface{}
+	return utils.SendPutRequest(prefs, "/api/v1/servers/"+agent.CloudServerID, renameServer{Name: name}, &resModel)
+}
@@ -0,0 +1,118 @@
+package agent
+
+import (
+	"io"
+	"log"
+
+	"fyne.io/fyne/v2"
+)
+
+// DefaultStopTimeout is the number of seconds an agent is given to shut down
+// before it is killed.
+const DefaultStopTimeout = 20
+
+func StartAgent(AgentName string, prefs fyne.Preferences) error {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return err
+	}
+
+	err = agent.checkTransition(StateRunning)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Starting Agent %s\r\n", agent.Name)
+
+	err = backend.Start(agent)
+	if err != nil {
+		agent.SetFailed(prefs, err)
+		return err
+	}
+
+	return agent.SetState(prefs, StateRunning)
+}
+
+func StopAgent(AgentName string, timeout int, prefs fyne.Preferences) error {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return err
+	}
+
+	err = agent.checkTransition(StateStopped)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Stopping Agent %s\r\n", agent.Name)
+
+	err = backend.Stop(agent, timeout)
+	if err != nil {
+		agent.SetFailed(prefs, err)
+		return err
+	}
+
+	return agent.SetState(prefs, StateStopped)
+}
+
+func RestartAgent(AgentName string, timeout int, prefs fyne.Preferences) error {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return err
+	}
+
+	err = agent.checkTransition(StateRunning)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Restarting Agent %s\r\n", agent.Name)
+
+	err = backend.Stop(agent, timeout)
+	if err == nil {
+		err = backend.Start(agent)
+	}
+
+	if err != nil {
+		agent.SetFailed(prefs, err)
+		return err
+	}
+
+	return agent.SetState(prefs, StateRunning)
+}
+
+// GetAgentStatus returns the live runtime status of an agent from its
+// backend.
+func GetAgentStatus(AgentName string) (AgentStatus, error) {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return AgentStatus{}, err
+	}
+
+	return backend.Status(agent)
+}
+
+// StreamAgentLogs writes the logs of an agent to stdout and stderr. When
+// following, it only returns once the log stream ends.
+func StreamAgentLogs(AgentName string, opts LogOptions, stdout io.Writer, stderr io.Writer) error {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return err
+	}
+
+	return backend.Logs(agent, opts, stdout, stderr)
+}
+
+func getAgentWithBackend(AgentName string) (*Agent, AgentBackend, error) {
+	agent, err := GetAgent(AgentName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	backend, err := GetAgentBackend(agent.AgentType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return agent, backend, nil
+}
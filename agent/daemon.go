@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+)
+
+const (
+	reconcileMinBackoff = 10 * time.Second
+	reconcileMaxBackoff = 10 * time.Minute
+	maxReconcileActions = 200
+)
+
+// ReconcileAction is a change the daemon made to bring an agent back to its
+// stored state.
+type ReconcileAction struct {
+	Time   time.Time `json:"time"`
+	Agent  string    `json:"agent"`
+	Action string    `json:"action"`
+	Error  string    `json:"error,omitempty"`
+}
+
+type reconcileBackoff struct {
+	attempts    int
+	nextAttempt time.Time
+}
+
+// Reconciler keeps agents that are stored as running actually running,
+// recreating missing containers and service files and restarting crashed
+// agents with an exponential backoff.
+type Reconciler struct {
+	prefs   fyne.Preferences
+	backoff map[string]*reconcileBackoff
+}
+
+func NewReconciler(prefs fyne.Preferences) *Reconciler {
+	return &Reconciler{
+		prefs:   prefs,
+		backoff: map[string]*reconcileBackoff{},
+	}
+}
+
+// Run reconciles every interval until the context is cancelled. A pass that
+// is in progress is finished before returning. Every pass reuses the cached
+// docker clients, which are closed when the reconciler stops.
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer CloseDockerClients()
+
+	for {
+		r.ReconcileOnce()
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			log.Println("Reconciler stopped")
+			return
+		}
+	}
+}
+
+func (r *Reconciler) ReconcileOnce() {
+	LoadAgents(r.prefs)
+
+	for idx := range AllAgents.Agents {
+		agent := &AllAgents.Agents[idx]
+		if agent.State != StateRunning {
+			delete(r.backoff, agent.Name)
+			continue
+		}
+
+		r.reconcileAgent(agent)
+	}
+
+	RefreshAgentHealth(r.prefs)
+}
+
+func (r *Reconciler) reconcileAgent(agent *Agent) {
+	backend, err := GetAgentBackend(agent.AgentType)
+	if err != nil {
+		return
+	}
+
+	backoff := r.backoff[agent.Name]
+	if backoff == nil {
+		backoff = &reconcileBackoff{}
+		r.backoff[agent.Name] = backoff
+	}
+
+	issues, err := backend.Diagnose(r.prefs, agent)
+	if err != nil {
+		log.Printf("Error checking agent %s, with error %s\r\n", agent.Name, err.Error())
+		return
+	}
+
+	// Only repair missing and stopped agents. Mismatched settings are left
+	// for doctor, since fixing them can mean recreating the container.
+	repairs := []DoctorIssue{}
+	for _, issue := range issues {
+		if issue.Fix != nil && (issue.Kind == IssueMissing || issue.Kind == IssueState) {
+			repairs = append(repairs, issue)
+		}
+	}
+
+	if len(repairs) == 0 {
+		status, err := backend.Status(agent)
+		if err == nil && status.Uptime() > reconcileMaxBackoff {
+			backoff.attempts = 0
+		}
+		return
+	}
+
+	if time.Now().Before(backoff.nextAttempt) {
+		return
+	}
+
+	for _, issue := range repairs {
+		err := issue.Fix()
+		r.recordAction(agent.Name, "repair: "+issue.Message, err)
+	}
+	SaveAgents(r.prefs)
+
+	delay := reconcileMinBackoff << backoff.attempts
+	if delay > reconcileMaxBackoff || delay <= 0 {
+		delay = reconcileMaxBackoff
+	}
+	backoff.attempts++
+	backoff.nextAttempt = time.Now().Add(delay)
+}
+
+func (r *Reconciler) recordAction(agentName string, action string, err error) {
+	reconcileAction := ReconcileAction{
+		Time:   time.Now().UTC(),
+		Agent:  agentName,
+		Action: action,
+	}
+
+	if err != nil {
+		reconcileAction.Error = err.Error()
+		log.Printf("Agent %s %s failed, with error %s\r\n", agentName, action, err.Error())
+	} else {
+		log.Printf("Agent %s %s\r\n", agentName, action)
+	}
+
+	actions := LoadReconcileActions(r.prefs)
+	actions = append(actions, reconcileAction)
+	if len(actions) > maxReconcileActions {
+		actions = actions[len(actions)-maxReconcileActions:]
+	}
+
+	b, err := json.Marshal(actions)
+	if err != nil || utils.SkipForDryRun("save daemon action log") {
+		return
+	}
+	r.prefs.SetString("reconcileActions", string(b))
+}
+
+// LoadReconcileActions returns the most recent actions taken by the daemon.
+func LoadReconcileActions(prefs fyne.Preferences) []ReconcileAction {
+	actions := []ReconcileAction{}
+	json.Unmarshal([]byte(prefs.StringWithFallback("reconcileActions", "[]")), &actions)
+	return actions
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// DiscoverAgents asks every backend for the agents installed on the host and
+// returns the ones that are not in the inventory. A backend that fails does
+// not stop the others, the agents found are returned with the errors joined.
+func DiscoverAgents() ([]Agent, error) {
+	agentTypes := make([]string, 0, len(agentBackends))
+	for agentType := range agentBackends {
+		agentTypes = append(agentTypes, agentType)
+	}
+	sort.Strings(agentTypes)
+
+	discovered := []Agent{}
+	backendErrors := []error{}
+	for _, agentType := range agentTypes {
+		agents, err := agentBackends[agentType].Discover()
+		if err != nil {
+			backendErrors = append(backendErrors, fmt.Errorf("%s agents: %w", agentType, err))
+		}
+
+		for _, agent := range agents {
+			if !isManagedAgent(&agent) {
+				discovered = append(discovered, agent)
+			}
+		}
+	}
+
+	return discovered, errors.Join(backendErrors...)
+}
+
+// AdoptAgent adds a discovered agent to the inventory.
+func AdoptAgent(agent Agent, prefs fyne.Preferences) error {
+	if isManagedAgent(&agent) {
+		return errors.New("agent already exists with the same name")
+	}
+
+	now := time.Now().UTC()
+	if agent.CreatedAt.IsZero() {
+		agent.CreatedAt = now
+	}
+	agent.StateChangedAt = now
+
+	log.Printf("Adopting Agent %s\r\n", agent.Name)
+
+	AllAgents.Agents = append(AllAgents.Agents, agent)
+	SaveAgents(prefs)
+	return nil
+}
+
+func isManagedAgent(agent *Agent) bool {
+	for idx := range AllAgents.Agents {
+		managed := &AllAgents.Agents[idx]
+		if managed.Name == agent.Name {
+			return true
+		}
+
+		if agent.DockerID != "" && managed.DockerID == agent.DockerID {
+			return true
+		}
+	}
+	return false
+}
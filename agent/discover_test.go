@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiscoverAgents(t *testing.T) {
+	errScan := errors.New("scan failed")
+
+	tests := []struct {
+		name      string
+		backends  map[string]*fakeBackend
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name: "all backends scanned",
+			backends: map[string]*fakeBackend{
+				"a": {discovered: []Agent{{Name: "agent1"}}},
+				"b": {discovered: []Agent{{Name: "agent2"}}},
+			},
+			wantNames: []string{"agent1", "agent2"},
+		},
+		{
+			name: "failing backend keeps the others",
+			backends: map[string]*fakeBackend{
+				"a": {discoverErr: errScan},
+				"b": {discovered: []Agent{{Name: "agent2"}}},
+			},
+			wantNames: []string{"agent2"},
+			wantErr:   true,
+		},
+		{
+			name: "partial results of a failing backend are kept",
+			backends: map[string]*fakeBackend{
+				"a": {discovered: []Agent{{Name: "agent1"}}, discoverErr: errScan},
+			},
+			wantNames: []string{"agent1"},
+			wantErr:   true,
+		},
+		{
+			name: "managed agents are skipped",
+			backends: map[string]*fakeBackend{
+				"a": {discovered: []Agent{{Name: "managed1"}, {Name: "agent1"}}},
+			},
+			wantNames: []string{"agent1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousBackends := agentBackends
+			previousAgents := AllAgents
+			t.Cleanup(func() {
+				agentBackends = previousBackends
+				AllAgents = previousAgents
+			})
+
+			agentBackends = map[string]AgentBackend{}
+			for agentType, backend := range tt.backends {
+				RegisterAgentBackend(agentType, backend)
+			}
+			AllAgents = Agents{Agents: []Agent{{Name: "managed1"}}}
+
+			discovered, err := DiscoverAgents()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DiscoverAgents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, errScan) {
+				t.Errorf("error should wrap the backend error, got %v", err)
+			}
+
+			names := []string{}
+			for _, agent := range discovered {
+				names = append(names, agent.Name)
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("discovered = %v, want %v", names, tt.wantNames)
+			}
+			for idx := range names {
+				if names[idx] != tt.wantNames[idx] {
+					t.Errorf("discovered = %v, want %v", names, tt.wantNames)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1027 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"github.com/docker/docker/api/types"
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+// dockerStableTime is how long a container without a healthcheck has to stay
+// running to be seen as healthy.
+const dockerStableTime = 15 * time.Second
+
+// dockerStatusTimeout limits how long a status check waits for docker, so an
+// unreachable endpoint does not hold up the health refresh of every agent.
+const dockerStatusTimeout = 10 * time.Second
+
+// DockerBackend runs agents as docker containers using the ssmagent image.
+type DockerBackend struct{}
+
+func (b *DockerBackend) Configure(agent *Agent) error {
+	if agent.Memory == 0 {
+		return errors.New("agent memory must be greater than 0")
+	}
+
+	err := validateAgentEndpoint(agent)
+	if err != nil {
+		return err
+	}
+
+	// Bind mount directories are created on the manager host, so agents on
+	// other docker hosts use named volumes.
+	if agent.Endpoint != "" && agent.DataDirectory != "" {
+		return errors.New("data directories can only be used on the local docker host, agents on other endpoints use named volumes")
+	}
+
+	err = validateAgentResources(agent)
+	if err != nil {
+		return err
+	}
+
+	if agent.RestartPolicy == "" {
+		agent.RestartPolicy = DefaultRestartPolicy
+	}
+
+	_, err = parseRestartPolicy(agent.RestartPolicy)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateImageReference(agent.Image)
+	if err != nil {
+		return err
+	}
+
+	err = validateDockerNetwork(agent)
+	if err != nil {
+		return err
+	}
+
+	if agent.HealthCheck.Type == "" {
+		agent.HealthCheck.Type = DefaultHealthCheck
+	}
+
+	err = validateHealthCheck(agent)
+	if err != nil {
+		return err
+	}
+
+	agent.InstallDirectory = ""
+	if agent.DataDirectory != "" {
+		dataDirectory, err := filepath.Abs(agent.DataDirectory)
+		if err != nil {
+			return err
+		}
+		agent.DataDirectory = filepath.Join(dataDirectory, agent.Name)
+	}
+
+	agent.Mounts = getDockerAgentMounts(agent)
+	return nil
+}
+
+func (b *DockerBackend) Capabilities() BackendCapabilities {
+	return BackendCapabilities{Container: true, RemoteEndpoints: true}
+}
+
+// Migrate fills in docker settings that did not exist when older agents were
+// saved. Their containers are updated by doctor.
+func (b *DockerBackend) Migrate(agent *Agent) {
+	if agent.RestartPolicy == "" {
+		agent.RestartPolicy = DefaultRestartPolicy
+	}
+
+	if agent.Image == "" {
+		agent.Image = DefaultAgentImage
+	}
+}
+
+// Location returns the short container id.
+func (b *DockerBackend) Location(agent *Agent) string {
+	if len(agent.DockerID) > 12 {
+		return agent.DockerID[:12]
+	}
+	return agent.DockerID
+}
+
+func (b *DockerBackend) Install(prefs fyne.Preferences, agent *Agent, opts InstallOptions) error {
+	return runTransaction([]transactionStep{
+		{
+			Name: "pull docker image",
+			Run: func() error {
+				if opts.NoPull {
+					return CheckDockerImageExists(agent)
+				}
+				return PullDockerImage(prefs, agent, opts.OnPullProgress)
+			},
+		},
+		{
+			Name:     "create docker container",
+			Run:      func() error { return CreateDockerContainer(prefs, agent) },
+			Rollback: func() error { return DeleteDockerContainer(prefs, agent) },
+		},
+	})
+}
+
+func (b *DockerBackend) Start(agent *Agent) error {
+	return StartDockerContainer(agent)
+}
+
+func (b *DockerBackend) Stop(agent *Agent, timeout int) error {
+	return StopDockerContainer(agent, timeout)
+}
+
+func (b *DockerBackend) Status(agent *Agent) (AgentStatus, error) {
+	status := AgentStatus{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dockerStatusTimeout)
+	defer cancel()
+
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return status, err
+	}
+
+	inspect, err := cli.ContainerInspect(ctx, agent.DockerID)
+	if err != nil {
+		return status, err
+	}
+
+	status.Running = inspect.State.Running
+	status.State = inspect.State.Status
+	status.PID = inspect.State.Pid
+	status.RestartCount = inspect.RestartCount
+	status.StartedAt, _ = time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+
+	if inspect.State.Health != nil {
+		status.Health = inspect.State.Health.Status
+	}
+	return status, nil
+}
+
+func (b *DockerBackend) Logs(agent *Agent, opts LogOptions, stdout io.Writer, stderr io.Writer) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	inspect, err := cli.ContainerInspect(ctx, agent.DockerID)
+	if err != nil {
+		return err
+	}
+
+	reader, err := cli.ContainerLogs(ctx, agent.DockerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Tail:       opts.Tail,
+		Since:      opts.Since,
+		Timestamps: opts.Timestamps,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// Containers with a tty send stdout and stderr as one raw stream,
+	// otherwise they are multiplexed and need splitting.
+	if inspect.Config.Tty {
+		_, err = io.Copy(stdout, reader)
+		return err
+	}
+
+	_, err = stdcopy.StdCopy(stdout, stderr, reader)
+	return err
+}
+
+// Upgrade pulls the target image and replaces the container with one created
+// from it. The old container is kept until the new one passes the health
+// gate, and is restored if it does not.
+func (b *DockerBackend) Upgrade(prefs fyne.Preferences, agent *Agent, opts UpgradeOptions) error {
+	previous := *agent
+
+	if opts.Image != "" {
+		err := ValidateImageReference(opts.Image)
+		if err != nil {
+			return err
+		}
+		agent.Image = opts.Image
+	}
+
+	steps := []transactionStep{
+		{
+			Name: "check docker container labels",
+			Run:  func() error { return CheckDockerContainerManaged(&previous) },
+		},
+		{
+			Name: "pull docker image",
+			Run:  func() error { return PullDockerImage(prefs, agent, opts.OnPullProgress) },
+		},
+	}
+	steps = append(steps, b.replaceContainerSteps(prefs, &previous, agent, agent.Name+"-upgrade-old", opts.HealthTimeout)...)
+
+	err := runTransaction(steps)
+
+	if err != nil {
+		agent.DockerID = previous.DockerID
+		agent.Image = previous.Image
+		agent.ImageDigest = previous.ImageDigest
+	}
+	return err
+}
+
+// replaceContainerSteps returns the transaction steps that replace the
+// container of previous with a new container for agent. The old container is
+// renamed out of the way instead of removed, so it can be restored until the
+// new container is running and healthy.
+func (b *DockerBackend) replaceContainerSteps(prefs fyne.Preferences, previous *Agent, agent *Agent, oldName string, healthTimeout time.Duration) []transactionStep {
+	wasRunning := false
+
+	return []transactionStep{
+		{
+			Name: "stop old docker container",
+			Run: func() error {
+				status, err := b.Status(previous)
+				if err != nil || !status.Running {
+					return err
+				}
+
+				wasRunning = true
+				return StopDockerContainer(previous, DefaultStopTimeout)
+			},
+			Rollback: func() error {
+				if !wasRunning {
+					return nil
+				}
+				return StartDockerContainer(previous)
+			},
+		},
+		{
+			Name:     "rename old docker container",
+			Run:      func() error { return renameDockerContainer(previous, oldName) },
+			Rollback: func() error { return renameDockerContainer(previous, previous.Name) },
+		},
+		{
+			Name: "create new docker container",
+			Run:  func() error { return CreateDockerContainer(prefs, agent) },
+			Rollback: func() error {
+				replacement := *agent
+				err := StopDockerContainer(&replacement, DefaultStopTimeout)
+				if err != nil {
+					return err
+				}
+				return DeleteDockerContainer(prefs, &replacement)
+			},
+		},
+		{
+			Name: "start new docker container",
+			Run: func() error {
+				// A stopped agent is left stopped, there is nothing to
+				// check its health against.
+				if !wasRunning {
+					return nil
+				}
+
+				err := StartDockerContainer(agent)
+				if err != nil {
+					return err
+				}
+				return waitForDockerContainerHealthy(agent, healthTimeout)
+			},
+		},
+		{
+			Name: "remove old docker container",
+			Run:  func() error { return DeleteDockerContainer(prefs, previous) },
+		},
+	}
+}
+
+func (b *DockerBackend) PlanUpdate(previous Agent, agent *Agent) ([]string, error) {
+	if agent.Memory <= 0 {
+		return nil, errors.New("agent memory must be greater than 0")
+	}
+
+	err := validateAgentResources(agent)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = parseRestartPolicy(agent.RestartPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ValidateImageReference(agent.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateDockerNetwork(agent)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateHealthCheck(agent)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dockerUpdateNeedsRecreate(previous, agent) || len(agent.Mounts) > 0 {
+		return nil, nil
+	}
+
+	return []string{"the container will be recreated and any data stored inside it will be lost"}, nil
+}
+
+// Update recreates the container when the ports, memory limit, image,
+// network or health check changed or a resource limit was removed, other
+// resource limits and the restart policy are applied to the running
+// container.
+func (b *DockerBackend) Update(prefs fyne.Preferences, previous Agent, agent *Agent) error {
+	if previous.Image != agent.Image {
+		err := PullDockerImage(prefs, agent, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dockerUpdateNeedsRecreate(previous, agent) {
+		return b.recreateDockerContainer(prefs, previous, agent)
+	}
+
+	return UpdateDockerContainer(agent)
+}
+
+func dockerUpdateNeedsRecreate(previous Agent, agent *Agent) bool {
+	return previous.PortOffset != agent.PortOffset ||
+		previous.Memory != agent.Memory ||
+		previous.Image != agent.Image ||
+		previous.Network != agent.Network ||
+		previous.HealthCheck != agent.HealthCheck ||
+		resourceLimitRemoved(previous.Resources, agent.Resources)
+}
+
+// Rename renames the container. The SSM_NAME in the container environment
+// keeps the previous name until the container is recreated.
+func (b *DockerBackend) Rename(prefs fyne.Preferences, previous Agent, agent *Agent) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	if utils.SkipForDryRun("rename docker container %s to %s", previous.Name, agent.Name) {
+		return nil
+	}
+
+	return cli.ContainerRename(ctx, agent.DockerID, agent.Name)
+}
+
+func renameDockerContainer(agent *Agent, name string) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	if utils.SkipForDryRun("rename docker container %s to %s", agent.DockerID, name) {
+		return nil
+	}
+
+	return cli.ContainerRename(ctx, agent.DockerID, name)
+}
+
+// waitForDockerContainerHealthy waits for the container to report healthy,
+// or to stay running for dockerStableTime if it has no healthcheck.
+func waitForDockerContainerHealthy(agent *Agent, timeout time.Duration) error {
+	if utils.IsDryRun() {
+		return nil
+	}
+
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		inspect, err := cli.ContainerInspect(ctx, agent.DockerID)
+		if err != nil {
+			return err
+		}
+
+		if !inspect.State.Running {
+			return fmt.Errorf("container stopped with exit code %d", inspect.State.ExitCode)
+		}
+
+		if inspect.State.Health != nil {
+			switch inspect.State.Health.Status {
+			case types.Healthy:
+				return nil
+			case types.Unhealthy:
+				return errors.New("container is unhealthy")
+			}
+		} else {
+			startedAt, _ := time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+			if time.Since(startedAt) >= dockerStableTime {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("container did not become healthy within %s", timeout)
+		}
+
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// Remove deletes the container, and the agent volumes and data directory
+// unless keepData is set.
+func (b *DockerBackend) Remove(prefs fyne.Preferences, agent *Agent, keepData bool) error {
+	if agent.DockerID != "" {
+		// A container the agent does not own is an error rather than
+		// skipped, so the record is kept for the container it points at.
+		err := CheckDockerContainerManaged(agent)
+		if err != nil {
+			return err
+		}
+
+		// Docker refuses to remove a running container, so it is stopped
+		// first like a standalone service.
+		status, err := b.Status(agent)
+		if err != nil {
+			return err
+		}
+
+		if status.Running {
+			err = StopDockerContainer(agent, DefaultStopTimeout)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = DeleteDockerContainer(prefs, agent)
+		if err != nil {
+			return err
+		}
+		agent.DockerID = ""
+	}
+
+	if keepData {
+		return nil
+	}
+
+	return RemoveDockerAgentData(agent)
+}
+
+// Discover scans the local docker host and every endpoint. An endpoint that
+// can not be scanned does not stop the others, the agents that were found are
+// returned along with the endpoint errors.
+func (b *DockerBackend) Discover() ([]Agent, error) {
+	agents := []Agent{}
+	endpointErrors := []error{}
+
+	for _, endpointName := range GetDockerEndpointNames() {
+		endpointAgents, err := discoverDockerEndpoint(normalizeEndpointName(endpointName))
+		if err != nil {
+			endpointErrors = append(endpointErrors, fmt.Errorf("docker endpoint %s: %w", endpointName, err))
+		}
+		agents = append(agents, endpointAgents...)
+	}
+
+	return agents, errors.Join(endpointErrors...)
+}
+
+func discoverDockerEndpoint(endpointName string) ([]Agent, error) {
+	ctx := context.Background()
+	cli, err := newDockerEndpointClient(endpointName)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: getManagedContainerFilter(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Containers created before the manager labels were added are found by
+	// the default agent image instead.
+	legacyContainers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("ancestor", "mrhid6/ssmagent")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range legacyContainers {
+		if !isManagedContainer(c.Labels) {
+			containers = append(containers, c)
+		}
+	}
+
+	// A container that can not be inspected, for example one removed since
+	// it was listed, is skipped so the other containers are still returned.
+	agents := make([]Agent, 0, len(containers))
+	inspectErrors := []error{}
+	for _, c := range containers {
+		inspect, err := cli.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			inspectErrors = append(inspectErrors, fmt.Errorf("container %s: %w", c.ID, err))
+			continue
+		}
+
+		agent := Agent{
+			Name:          strings.TrimPrefix(inspect.Name, "/"),
+			AgentType:     "docker",
+			DockerID:      inspect.ID,
+			Memory:        int(inspect.HostConfig.Memory),
+			Resources:     getAgentResources(inspect.HostConfig.Resources),
+			Mounts:        getAgentMounts(inspect.HostConfig.Mounts),
+			RestartPolicy: formatRestartPolicy(inspect.HostConfig.RestartPolicy),
+			Image:         inspect.Config.Image,
+			Network:       getAgentNetwork(inspect.HostConfig),
+			HealthCheck:   getAgentHealthCheck(inspect.Config.Healthcheck),
+			ImageDigest:   getImageDigest(ctx, cli, inspect.Image, inspect.Config.Image),
+			Endpoint:      endpointName,
+			State:         StateStopped,
+		}
+
+		if inspect.State.Running {
+			agent.State = StateRunning
+		}
+
+		agent.CreatedAt, _ = time.Parse(time.RFC3339Nano, inspect.Created)
+
+		for _, env := range inspect.Config.Env {
+			key, value, _ := strings.Cut(env, "=")
+			if key == "SSM_APIKEY" {
+				agent.APIKey = value
+			}
+		}
+
+		bindings := inspect.HostConfig.PortBindings["15777/udp"]
+		if portOffset, err := strconv.Atoi(inspect.Config.Labels[LabelPortOffset]); err == nil {
+			agent.PortOffset = portOffset
+		} else if len(bindings) > 0 {
+			hostPort, err := strconv.Atoi(bindings[0].HostPort)
+			if err == nil {
+				agent.PortOffset = hostPort - 15777
+			}
+		}
+
+		agents = append(agents, agent)
+	}
+
+	return agents, errors.Join(inspectErrors...)
+}
+
+func (b *DockerBackend) Diagnose(prefs fyne.Preferences, agent *Agent) ([]DoctorIssue, error) {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return nil, err
+	}
+
+	inspect, err := cli.ContainerInspect(ctx, agent.DockerID)
+	if client.IsErrNotFound(err) || agent.DockerID == "" {
+		return []DoctorIssue{{
+			Agent:   agent.Name,
+			Kind:    IssueMissing,
+			Message: "docker container does not exist",
+			Fix: func() error {
+				err := PullDockerImage(prefs, agent, nil)
+				if err != nil {
+					return err
+				}
+				return CreateDockerContainer(prefs, agent)
+			},
+		}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Every issue fixed by recreating the container shares one recreate, so
+	// a container with several kinds of drift is only replaced once. The
+	// image is pulled first if it changed, the new container is created from
+	// it. The record holds the wanted settings, so it is also the previous
+	// agent the container is restored from.
+	imageChanged := !isSameImage(inspect.Config.Image, agent.Image)
+	recreated := false
+	var recreateErr error
+	recreate := func() error {
+		if recreated {
+			return recreateErr
+		}
+		recreated = true
+
+		if imageChanged {
+			recreateErr = PullDockerImage(prefs, agent, nil)
+			if recreateErr != nil {
+				return recreateErr
+			}
+		}
+
+		recreateErr = b.recreateDockerContainer(prefs, *agent, agent)
+		return recreateErr
+	}
+
+	issues := []DoctorIssue{}
+
+	if !isManagedContainer(inspect.Config.Labels) {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: "docker container has no agent manager labels, recreating it adds them",
+			Fix:     recreate,
+		})
+	}
+
+	expectedPort := strconv.Itoa(15777 + agent.PortOffset)
+	bindings := inspect.HostConfig.PortBindings["15777/udp"]
+	hostNetwork := agent.Network.GetMode() == NetworkHost
+	if !hostNetwork && (len(bindings) == 0 || bindings[0].HostPort != expectedPort) {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: "container ports do not match port offset " + strconv.Itoa(agent.PortOffset),
+			Fix:     recreate,
+		})
+	}
+
+	containerNetwork := getAgentNetwork(inspect.HostConfig)
+	if !isSameNetwork(agent.Network, containerNetwork) {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: fmt.Sprintf("container network is %s, expected %s", containerNetwork, agent.Network),
+			Fix:     recreate,
+		})
+	}
+
+	if int(inspect.HostConfig.Memory) != agent.Memory {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: fmt.Sprintf("container memory limit is %d bytes, expected %d bytes", inspect.HostConfig.Memory, agent.Memory),
+			Fix:     recreate,
+		})
+	}
+
+	if len(agent.Mounts) == 0 {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: "container has no persistent volumes, its data is lost when it is recreated",
+		})
+	} else if !reflect.DeepEqual(getAgentMounts(inspect.HostConfig.Mounts), agent.Mounts) {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: "container volumes do not match the agent volumes",
+			Fix:     recreate,
+		})
+	}
+
+	if containerResources := getAgentResources(inspect.HostConfig.Resources); containerResources != agent.Resources {
+		fix := func() error { return UpdateDockerContainer(agent) }
+		if resourceLimitRemoved(containerResources, agent.Resources) {
+			fix = recreate
+		}
+
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: "container resource limits do not match the agent limits",
+			Fix:     fix,
+		})
+	}
+
+	if imageChanged {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: fmt.Sprintf("container image is %s, expected %s", inspect.Config.Image, agent.Image),
+			Fix:     recreate,
+		})
+	}
+
+	containerRestartPolicy := formatRestartPolicy(inspect.HostConfig.RestartPolicy)
+	if containerRestartPolicy != agent.RestartPolicy {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: fmt.Sprintf("container restart policy is %s, expected %s", containerRestartPolicy, agent.RestartPolicy),
+			Fix:     func() error { return UpdateDockerContainer(agent) },
+		})
+	}
+
+	// Agents without a health check type keep the healthcheck of the image.
+	containerHealthCheck := getAgentHealthCheck(inspect.Config.Healthcheck)
+	if agent.HealthCheck.Type != "" && containerHealthCheck != agent.HealthCheck {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: fmt.Sprintf("container health check is %s, expected %s", valueOrNone(containerHealthCheck.String()), agent.HealthCheck),
+			Fix:     recreate,
+		})
+	}
+
+	status := AgentStatus{Running: inspect.State.Running, State: inspect.State.Status}
+	if inspect.State.Health != nil {
+		status.Health = inspect.State.Health.Status
+	}
+	issues = append(issues, diagnoseRunState(b, agent, status)...)
+
+	return issues, nil
+}
+
+// PullDockerImage pulls the agent image and waits for the pull to finish,
+// passing the progress events to onProgress if it is not nil.
+func PullDockerImage(prefs fyne.Preferences, agent *Agent, onProgress func(PullProgress)) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	registryAuth, err := getRegistryAuth(prefs)
+	if err != nil {
+		return err
+	}
+
+	if utils.SkipForDryRun("pull docker image %s", agent.Image) {
+		return nil
+	}
+
+	log.Printf("Pulling docker image %s\r\n", agent.Image)
+	reader, err := cli.ImagePull(ctx, agent.Image, types.ImagePullOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	err = readPullStream(reader, onProgress)
+	if err != nil {
+		return fmt.Errorf("pulling docker image failed: %w", err)
+	}
+
+	return nil
+}
+
+// CheckDockerImageExists returns an error if the agent image has not been
+// pulled on the host.
+func CheckDockerImageExists(agent *Agent) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = cli.ImageInspectWithRaw(ctx, agent.Image)
+	if client.IsErrNotFound(err) {
+		return errors.New("docker image " + agent.Image + " is not on the host, pull it first")
+	}
+	return err
+}
+
+func CreateDockerContainer(prefs fyne.Preferences, agent *Agent) error {
+	log.Println("Creating SSM Agent docker container...")
+
+	var serverPort = 15777 + agent.PortOffset
+	var beaconPort = 15000 + agent.PortOffset
+	var port = 7777 + agent.PortOffset
+
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	var envStrings = []string{
+		"SSM_NAME=" + agent.Name,
+		"SSM_URL=" + utils.GetSSMURL(prefs),
+		"SSM_APIKEY=" + agent.APIKey,
+	}
+
+	var hostIP = "0.0.0.0"
+	if agent.Network.BindIP != "" {
+		hostIP = agent.Network.BindIP
+	}
+
+	var portBindings = nat.PortMap{
+		"15777/udp": []nat.PortBinding{
+			{
+				HostIP:   hostIP,
+				HostPort: strconv.Itoa(serverPort),
+			},
+		},
+		"15000/udp": []nat.PortBinding{
+			{
+				HostIP:   hostIP,
+				HostPort: strconv.Itoa(beaconPort),
+			},
+		},
+		"7777/udp": []nat.PortBinding{
+			{
+				HostIP:   hostIP,
+				HostPort: strconv.Itoa(port),
+			},
+		},
+	}
+
+	var exposedPorts = nat.PortSet{
+		"15777/udp": struct{}{},
+		"15000/udp": struct{}{},
+		"7777/udp":  struct{}{},
+	}
+
+	// The host network has no NAT, the ports are used as they are.
+	if agent.Network.GetMode() == NetworkHost {
+		portBindings = nil
+		exposedPorts = nil
+	}
+
+	err = ensureDockerNetwork(agent)
+	if err != nil {
+		return err
+	}
+
+	err = createBindMountDirectories(agent)
+	if err != nil {
+		return err
+	}
+
+	restartPolicy, err := parseRestartPolicy(agent.RestartPolicy)
+	if err != nil {
+		return err
+	}
+
+	if utils.SkipForDryRun("create docker container %s", agent.Name) {
+		return nil
+	}
+
+	resp, err := cli.ContainerCreate(ctx, &dockerContainer.Config{
+		Image:        agent.Image,
+		Tty:          true,
+		Env:          envStrings,
+		ExposedPorts: exposedPorts,
+		Labels:       getDockerAgentLabels(agent),
+		Healthcheck:  getDockerHealthConfig(agent),
+	}, &dockerContainer.HostConfig{
+		NetworkMode:   getDockerNetworkMode(agent),
+		PortBindings:  portBindings,
+		Resources:     getDockerResources(agent),
+		Mounts:        getDockerMounts(agent),
+		RestartPolicy: restartPolicy,
+	}, nil, nil, agent.Name)
+
+	if err != nil {
+		return err
+	}
+	agent.DockerID = resp.ID
+
+	// The step failing means its rollback is not run, so remove the new
+	// container here instead of leaking it.
+	inspect, err := cli.ContainerInspect(ctx, agent.DockerID)
+	if err != nil {
+		cli.ContainerRemove(ctx, agent.DockerID, types.ContainerRemoveOptions{Force: true})
+		agent.DockerID = ""
+		return err
+	}
+	agent.ImageDigest = getImageDigest(ctx, cli, inspect.Image, agent.Image)
+
+	log.Println("SSM Agent Docker container created successfully")
+	return nil
+}
+
+// UpdateDockerContainer applies the agent resource limits and restart policy
+// to the existing container without recreating it.
+func UpdateDockerContainer(agent *Agent) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	restartPolicy, err := parseRestartPolicy(agent.RestartPolicy)
+	if err != nil {
+		return err
+	}
+
+	if utils.SkipForDryRun("update docker container %s resource limits and restart policy", agent.Name) {
+		return nil
+	}
+
+	// Docker leaves the pids limit alone when it is not set, -1 removes it.
+	resources := getDockerResources(agent)
+	if resources.PidsLimit == nil {
+		noPidsLimit := int64(-1)
+		resources.PidsLimit = &noPidsLimit
+	}
+
+	_, err = cli.ContainerUpdate(ctx, agent.DockerID, dockerContainer.UpdateConfig{
+		Resources:     resources,
+		RestartPolicy: restartPolicy,
+	})
+	return err
+}
+
+func DeleteDockerContainer(prefs fyne.Preferences, agent *Agent) error {
+
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	// Only containers created by the manager are removed. An agent without
+	// a container id has nothing to check, which happens in dry runs.
+	if agent.DockerID != "" {
+		err = CheckDockerContainerManaged(agent)
+		if err != nil {
+			return err
+		}
+	}
+
+	if utils.SkipForDryRun("remove docker container %s", agent.Name) {
+		return nil
+	}
+
+	// The agent volumes are kept so the container can be recreated, they
+	// are removed with RemoveDockerAgentData.
+	return cli.ContainerRemove(ctx, agent.DockerID, types.ContainerRemoveOptions{})
+}
+
+func StartDockerContainer(agent *Agent) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	if utils.SkipForDryRun("start docker container %s", agent.Name) {
+		return nil
+	}
+
+	return cli.ContainerStart(ctx, agent.DockerID, types.ContainerStartOptions{})
+}
+
+func StopDockerContainer(agent *Agent, timeout int) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	if utils.SkipForDryRun("stop docker container %s", agent.Name) {
+		return nil
+	}
+
+	return cli.ContainerStop(ctx, agent.DockerID, dockerContainer.StopOptions{Timeout: &timeout})
+}
+
+// recreateDockerContainer replaces the container with one created from the
+// updated agent settings, restoring the old container if the new one fails to
+// start.
+func (b *DockerBackend) recreateDockerContainer(prefs fyne.Preferences, previous Agent, agent *Agent) error {
+	healthTimeout := DefaultUpgradeHealthTimeout
+	if settleTime := agent.HealthCheck.settleTime(); healthTimeout < settleTime {
+		healthTimeout = settleTime
+	}
+
+	steps := []transactionStep{
+		{
+			Name: "check docker container labels",
+			Run:  func() error { return CheckDockerContainerManaged(&previous) },
+		},
+	}
+	steps = append(steps, b.replaceContainerSteps(prefs, &previous, agent, agent.Name+"-update-old", healthTimeout)...)
+
+	err := runTransaction(steps)
+	if err != nil {
+		agent.DockerID = previous.DockerID
+	}
+	return err
+}
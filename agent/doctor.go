@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+)
+
+const (
+	IssueMissing  = "missing"
+	IssueOrphaned = "orphaned"
+	IssueMismatch = "mismatch"
+	IssueState    = "state"
+)
+
+// DoctorIssue is a difference between an agent record and what is actually
+// installed. Fix is nil when the issue can not be repaired automatically.
+type DoctorIssue struct {
+	Agent   string       `json:"agent"`
+	Kind    string       `json:"kind"`
+	Message string       `json:"message"`
+	Fix     func() error `json:"-"`
+}
+
+// RunDoctor compares every stored agent with its backend, the filesystem and
+// SSM Cloud, and reports agents that are installed but not in the inventory.
+func RunDoctor(prefs fyne.Preferences) ([]DoctorIssue, error) {
+	issues := []DoctorIssue{}
+
+	for idx := range AllAgents.Agents {
+		agent := &AllAgents.Agents[idx]
+
+		if agent.State == StateFailed || agent.State == StateProvisioning || agent.State == StateDeleting {
+			issues = append(issues, DoctorIssue{
+				Agent:   agent.Name,
+				Kind:    IssueState,
+				Message: fmt.Sprintf("agent is %s: %s", agent.State, agent.LastError),
+			})
+		}
+
+		backend, err := GetAgentBackend(agent.AgentType)
+		if err != nil {
+			issues = append(issues, DoctorIssue{Agent: agent.Name, Kind: IssueMismatch, Message: err.Error()})
+			continue
+		}
+
+		backendIssues, err := backend.Diagnose(prefs, agent)
+		if err != nil {
+			issues = append(issues, DoctorIssue{
+				Agent:   agent.Name,
+				Kind:    IssueMismatch,
+				Message: "could not check agent: " + err.Error(),
+			})
+		}
+		issues = append(issues, backendIssues...)
+
+		cloudIssue := diagnoseCloudServer(prefs, agent)
+		if cloudIssue != nil {
+			issues = append(issues, *cloudIssue)
+		}
+	}
+
+	orphaned, err := DiscoverAgents()
+	if err != nil {
+		issues = append(issues, DoctorIssue{
+			Kind:    IssueMismatch,
+			Message: "could not scan for orphaned agents: " + err.Error(),
+		})
+	}
+
+	for _, orphan := range orphaned {
+		orphan := orphan
+		issues = append(issues, DoctorIssue{
+			Agent:   orphan.Name,
+			Kind:    IssueOrphaned,
+			Message: fmt.Sprintf("%s agent is installed but not in the inventory", orphan.AgentType),
+			Fix:     func() error { return AdoptAgent(orphan, prefs) },
+		})
+	}
+
+	return issues, nil
+}
+
+// FixDoctorIssues runs the fix of every issue that has one and returns the
+// errors of the fixes that failed.
+func FixDoctorIssues(issues []DoctorIssue, prefs fyne.Preferences) []error {
+	fixErrors := []error{}
+
+	for _, issue := range issues {
+		if issue.Fix == nil {
+			continue
+		}
+
+		err := issue.Fix()
+		if err != nil {
+			fixErrors = append(fixErrors, fmt.Errorf("%s: %s: %w", issue.Agent, issue.Message, err))
+		}
+	}
+
+	SaveAgents(prefs)
+	return fixErrors
+}
+
+func diagnoseCloudServer(prefs fyne.Preferences, agent *Agent) *DoctorIssue {
+	if agent.CloudServerID == "" {
+		return &DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMissing,
+			Message: "agent has no ssm cloud server id",
+		}
+	}
+
+	var resModel interface{}
+	err := utils.SendGetRequest(prefs, "/api/v1/servers/"+agent.CloudServerID, &resModel)
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *utils.APIError
+	if errors.As(err, &apiErr) && apiErr.ResponseCode == http.StatusNotFound {
+		return &DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMissing,
+			Message: "ssm cloud server does not exist",
+		}
+	}
+
+	return &DoctorIssue{
+		Agent:   agent.Name,
+		Kind:    IssueMismatch,
+		Message: "could not check ssm cloud server: " + err.Error(),
+	}
+}
+
+// diagnoseRunState reports an agent that is stored as running but is not,
+// or that is running but failing its health check.
+func diagnoseRunState(backend AgentBackend, agent *Agent, status AgentStatus) []DoctorIssue {
+	if agent.State != StateRunning {
+		return nil
+	}
+
+	if status.Running && status.Health == "unhealthy" {
+		return []DoctorIssue{{
+			Agent:   agent.Name,
+			Kind:    IssueState,
+			Message: "agent is running but unhealthy",
+			Fix: func() error {
+				err := backend.Stop(agent, DefaultStopTimeout)
+				if err != nil {
+					return err
+				}
+				return backend.Start(agent)
+			},
+		}}
+	}
+
+	if status.Running {
+		return nil
+	}
+
+	return []DoctorIssue{{
+		Agent:   agent.Name,
+		Kind:    IssueState,
+		Message: "agent should be running but is " + status.State,
+		Fix:     func() error { return backend.Start(agent) },
+	}}
+}
@@ -0,0 +1,453 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"github.com/docker/docker/client"
+)
+
+// LocalEndpointName is the docker endpoint agents use when they are not
+// bound to one. It connects to the docker host from the environment, the
+// local docker socket by default.
+const LocalEndpointName = "local"
+
+var (
+	AllDockerEndpoints []DockerEndpoint
+
+	// dockerEndpointsErr is set when the saved endpoints could not be
+	// loaded. Endpoints are not saved while it is set, so a corrupt config
+	// is left for the user to fix rather than replaced.
+	dockerEndpointsErr error
+)
+
+// dockerClients caches one client per endpoint name. Clients are reused
+// because every client to an ssh endpoint keeps ssh processes running until
+// it is closed, and agent health is refreshed every few seconds.
+//
+// Clients of changed or removed endpoints may still be in use by another
+// goroutine, so they are retired and only closed by CloseDockerClients.
+var (
+	dockerClients        = map[string]cachedDockerClient{}
+	retiredDockerClients = []*client.Client{}
+	dockerClientsLock    sync.Mutex
+)
+
+type cachedDockerClient struct {
+	// endpoint is the endpoint the client was created for, so a client is
+	// replaced when its endpoint is changed.
+	endpoint DockerEndpoint
+	cli      *client.Client
+}
+
+// DockerEndpoint is a named docker host agents can be placed on. Host is a
+// docker host url like unix:///var/run/docker.sock, tcp://host:2376 or
+// ssh://user@host. The TLS paths are only used with tcp hosts.
+type DockerEndpoint struct {
+	Name      string `json:"name"`
+	Host      string `json:"host"`
+	TLSCACert string `json:"tlsCaCert,omitempty"`
+	TLSCert   string `json:"tlsCert,omitempty"`
+	TLSKey    string `json:"tlsKey,omitempty"`
+}
+
+func (e DockerEndpoint) usesTLS() bool {
+	return e.TLSCACert != "" || e.TLSCert != "" || e.TLSKey != ""
+}
+
+// validateDockerEndpoint checks the endpoint host url and TLS settings.
+func validateDockerEndpoint(endpoint DockerEndpoint) error {
+	if endpoint.Name == "" {
+		return errors.New("docker endpoint name can not be empty")
+	}
+
+	if endpoint.Name == LocalEndpointName {
+		return errors.New("docker endpoint name " + LocalEndpointName + " is reserved for the local docker host")
+	}
+
+	hostURL, err := url.Parse(endpoint.Host)
+	if err != nil {
+		return fmt.Errorf("docker endpoint host %s is not a url: %w", endpoint.Host, err)
+	}
+
+	switch hostURL.Scheme {
+	case "unix", "ssh":
+		if endpoint.usesTLS() {
+			return errors.New("TLS client certs can only be used with tcp docker endpoints")
+		}
+	case "tcp":
+		if endpoint.usesTLS() && (endpoint.TLSCACert == "" || endpoint.TLSCert == "" || endpoint.TLSKey == "") {
+			return errors.New("tcp docker endpoints need the TLS ca cert, cert and key together")
+		}
+	default:
+		return errors.New("docker endpoint host must start with unix://, tcp:// or ssh://")
+	}
+
+	if hostURL.Scheme == "ssh" && hostURL.Host == "" {
+		return errors.New("ssh docker endpoints need a host like ssh://user@host")
+	}
+
+	return nil
+}
+
+func LoadDockerEndpoints(prefs fyne.Preferences) error {
+	AllDockerEndpoints = []DockerEndpoint{}
+	dockerEndpointsErr = nil
+
+	err := json.Unmarshal([]byte(prefs.StringWithFallback("dockerendpoints", "[]")), &AllDockerEndpoints)
+	if err != nil {
+		AllDockerEndpoints = []DockerEndpoint{}
+		dockerEndpointsErr = fmt.Errorf("the saved docker endpoints could not be loaded: %w", err)
+	}
+	return dockerEndpointsErr
+}
+
+func SaveDockerEndpoints(prefs fyne.Preferences) {
+	if utils.SkipForDryRun("save docker endpoints") {
+		return
+	}
+
+	b, err := json.Marshal(AllDockerEndpoints)
+	if err != nil {
+		panic(err)
+	}
+	prefs.SetString("dockerendpoints", string(b))
+}
+
+func GetDockerEndpoint(name string) (*DockerEndpoint, error) {
+	for idx := range AllDockerEndpoints {
+		if AllDockerEndpoints[idx].Name == name {
+			return &AllDockerEndpoints[idx], nil
+		}
+	}
+	return nil, errors.New("docker endpoint " + name + " does not exist")
+}
+
+// GetDockerEndpointNames returns the local endpoint followed by the
+// configured endpoints.
+func GetDockerEndpointNames() []string {
+	names := []string{LocalEndpointName}
+	for _, endpoint := range AllDockerEndpoints {
+		names = append(names, endpoint.Name)
+	}
+	return names
+}
+
+// SetDockerEndpoint adds an endpoint, or replaces the endpoint with the same
+// name.
+func SetDockerEndpoint(endpoint DockerEndpoint, prefs fyne.Preferences) error {
+	if dockerEndpointsErr != nil {
+		return dockerEndpointsErr
+	}
+
+	err := validateDockerEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+
+	existing, err := GetDockerEndpoint(endpoint.Name)
+	if err == nil {
+		*existing = endpoint
+	} else {
+		AllDockerEndpoints = append(AllDockerEndpoints, endpoint)
+	}
+
+	SaveDockerEndpoints(prefs)
+	return nil
+}
+
+// RemoveDockerEndpoint removes an endpoint that no agent is bound to.
+func RemoveDockerEndpoint(name string, prefs fyne.Preferences) error {
+	if dockerEndpointsErr != nil {
+		return dockerEndpointsErr
+	}
+
+	_, err := GetDockerEndpoint(name)
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range AllAgents.Agents {
+		if agent.Endpoint == name {
+			return errors.New("docker endpoint " + name + " is used by agent " + agent.Name)
+		}
+	}
+
+	endpoints := []DockerEndpoint{}
+	for _, endpoint := range AllDockerEndpoints {
+		if endpoint.Name != name {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	AllDockerEndpoints = endpoints
+
+	dockerClientsLock.Lock()
+	retireDockerClient(name)
+	dockerClientsLock.Unlock()
+
+	SaveDockerEndpoints(prefs)
+	return nil
+}
+
+// GetAgentHost returns the docker host an agent is placed on. Standalone
+// agents always run on the manager host.
+func GetAgentHost(agent *Agent) string {
+	if !agent.Capabilities().RemoteEndpoints || agent.Endpoint == "" {
+		return LocalEndpointName
+	}
+
+	endpoint, err := GetDockerEndpoint(agent.Endpoint)
+	if err != nil {
+		return agent.Endpoint + " (missing)"
+	}
+	return endpoint.Host
+}
+
+func isLocalEndpoint(endpointName string) bool {
+	return endpointName == "" || endpointName == LocalEndpointName
+}
+
+// normalizeEndpointName stores the local endpoint as an empty name.
+func normalizeEndpointName(endpointName string) string {
+	if isLocalEndpoint(endpointName) {
+		return ""
+	}
+	return endpointName
+}
+
+// validateAgentEndpoint checks the agent endpoint exists and normalises the
+// local endpoint to an empty name.
+func validateAgentEndpoint(agent *Agent) error {
+	agent.Endpoint = normalizeEndpointName(agent.Endpoint)
+	if agent.Endpoint == "" {
+		return nil
+	}
+
+	_, err := GetDockerEndpoint(agent.Endpoint)
+	return err
+}
+
+// newDockerClient returns a docker client for the endpoint the agent is
+// bound to.
+func newDockerClient(agent *Agent) (*client.Client, error) {
+	return newDockerEndpointClient(agent.Endpoint)
+}
+
+// newDockerEndpointClient returns the cached client of an endpoint, creating
+// it on first use. Callers must not close the client, see CloseDockerClients.
+func newDockerEndpointClient(endpointName string) (*client.Client, error) {
+	endpoint := DockerEndpoint{Name: LocalEndpointName}
+	if !isLocalEndpoint(endpointName) {
+		configured, err := GetDockerEndpoint(endpointName)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = *configured
+	}
+
+	dockerClientsLock.Lock()
+	defer dockerClientsLock.Unlock()
+
+	cached, ok := dockerClients[endpoint.Name]
+	if ok && cached.endpoint == endpoint {
+		return cached.cli, nil
+	}
+	if ok {
+		retireDockerClient(endpoint.Name)
+	}
+
+	cli, err := createDockerClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerClients[endpoint.Name] = cachedDockerClient{endpoint: endpoint, cli: cli}
+	return cli, nil
+}
+
+// CloseDockerClients closes the cached and retired docker clients, ending
+// any ssh connections to remote endpoints. It is called when the manager
+// exits, once no agent actions are running.
+func CloseDockerClients() {
+	dockerClientsLock.Lock()
+	defer dockerClientsLock.Unlock()
+
+	for name, cached := range dockerClients {
+		cached.cli.Close()
+		delete(dockerClients, name)
+	}
+
+	for _, cli := range retiredDockerClients {
+		cli.Close()
+	}
+	retiredDockerClients = []*client.Client{}
+}
+
+// retireDockerClient drops the cached client of an endpoint without closing
+// it. dockerClientsLock must be held.
+func retireDockerClient(endpointName string) {
+	cached, ok := dockerClients[endpointName]
+	if ok {
+		retiredDockerClients = append(retiredDockerClients, cached.cli)
+		delete(dockerClients, endpointName)
+	}
+}
+
+func createDockerClient(endpoint DockerEndpoint) (*client.Client, error) {
+	if endpoint.Name == LocalEndpointName {
+		return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	}
+
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+
+	if strings.HasPrefix(endpoint.Host, "ssh://") {
+		// Docker has no native ssh transport, so the api is tunnelled
+		// through docker system dial-stdio on the remote host. The host
+		// name is a placeholder since every request uses the tunnel.
+		opts = append(opts,
+			client.WithHost("http://docker.example.com"),
+			client.WithDialContext(newSSHDialer(endpoint.Host)),
+		)
+	} else {
+		opts = append(opts, client.WithHost(endpoint.Host))
+		if endpoint.usesTLS() {
+			opts = append(opts, client.WithTLSClientConfig(endpoint.TLSCACert, endpoint.TLSCert, endpoint.TLSKey))
+		}
+	}
+
+	return client.NewClientWithOpts(opts...)
+}
+
+func newSSHDialer(host string) func(ctx context.Context, network string, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		hostURL, err := url.Parse(host)
+		if err != nil {
+			return nil, err
+		}
+
+		args := []string{}
+		if hostURL.Port() != "" {
+			args = append(args, "-p", hostURL.Port())
+		}
+
+		target := hostURL.Hostname()
+		if hostURL.User != nil {
+			target = hostURL.User.Username() + "@" + target
+		}
+		args = append(args, "--", target, "docker", "system", "dial-stdio")
+
+		// The command outlives the dial context, since the connection is
+		// kept open for later requests.
+		cmd := exec.Command("ssh", args...)
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+
+		err = cmd.Start()
+		if err != nil {
+			return nil, err
+		}
+
+		return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+	}
+}
+
+// commandConn is a net.Conn over the stdin and stdout of a command. Pipes
+// have no deadlines, so the connection is closed when a deadline passes
+// instead, failing any blocked read or write.
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+
+	closeOnce     sync.Once
+	deadlinesLock sync.Mutex
+	readDeadline  *time.Timer
+	writeDeadline *time.Timer
+}
+
+func (c *commandConn) Read(b []byte) (int, error) {
+	return c.stdout.Read(b)
+}
+
+func (c *commandConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.stdout.Close()
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return commandAddr{}
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return commandAddr{}
+}
+
+func (c *commandConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *commandConn) SetReadDeadline(t time.Time) error {
+	c.deadlinesLock.Lock()
+	defer c.deadlinesLock.Unlock()
+
+	c.readDeadline = c.resetDeadline(c.readDeadline, t)
+	return nil
+}
+
+func (c *commandConn) SetWriteDeadline(t time.Time) error {
+	c.deadlinesLock.Lock()
+	defer c.deadlinesLock.Unlock()
+
+	c.writeDeadline = c.resetDeadline(c.writeDeadline, t)
+	return nil
+}
+
+// resetDeadline stops the timer of the previous deadline and returns a timer
+// that closes the connection at t. A zero t clears the deadline.
+func (c *commandConn) resetDeadline(timer *time.Timer, t time.Time) *time.Timer {
+	if timer != nil {
+		timer.Stop()
+	}
+
+	if t.IsZero() {
+		return nil
+	}
+	return time.AfterFunc(time.Until(t), func() { c.Close() })
+}
+
+type commandAddr struct{}
+
+func (commandAddr) Network() string {
+	return "command"
+}
+
+func (commandAddr) String() string {
+	return "command"
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestDockerEndpointClientCache(t *testing.T) {
+	previous := AllDockerEndpoints
+	t.Cleanup(func() {
+		AllDockerEndpoints = previous
+		CloseDockerClients()
+	})
+	AllDockerEndpoints = []DockerEndpoint{{Name: "remote1", Host: "tcp://10.0.0.1:2375"}}
+
+	first, err := newDockerEndpointClient("remote1")
+	if err != nil {
+		t.Fatalf("newDockerEndpointClient() error = %v", err)
+	}
+
+	second, err := newDockerEndpointClient("remote1")
+	if err != nil {
+		t.Fatalf("newDockerEndpointClient() error = %v", err)
+	}
+	if first != second {
+		t.Error("clients of the same endpoint should be reused")
+	}
+
+	AllDockerEndpoints[0].Host = "tcp://10.0.0.2:2375"
+	changed, err := newDockerEndpointClient("remote1")
+	if err != nil {
+		t.Fatalf("newDockerEndpointClient() error = %v", err)
+	}
+	if changed == first {
+		t.Error("a changed endpoint should get a new client")
+	}
+	if changed.DaemonHost() != "tcp://10.0.0.2:2375" {
+		t.Errorf("client host = %s, want the changed host", changed.DaemonHost())
+	}
+	if len(retiredDockerClients) != 1 || retiredDockerClients[0] != first {
+		t.Error("the client of a changed endpoint should be retired rather than closed")
+	}
+
+	CloseDockerClients()
+	if len(dockerClients) != 0 || len(retiredDockerClients) != 0 {
+		t.Error("closed clients should be dropped from the cache")
+	}
+
+	_, err = newDockerEndpointClient("missing")
+	if err == nil {
+		t.Error("unknown endpoints should be an error")
+	}
+}
+
+func TestCommandConnDeadline(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("can not run sleep: %v", err)
+	}
+
+	conn := &commandConn{cmd: cmd, stdin: stdin, stdout: stdout}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("read should fail once the deadline passed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("read was not interrupted by the deadline")
+	}
+}
+
+func TestLoadCorruptDockerEndpoints(t *testing.T) {
+	previous := AllDockerEndpoints
+	t.Cleanup(func() {
+		AllDockerEndpoints = previous
+		dockerEndpointsErr = nil
+	})
+
+	prefs := test.NewApp().Preferences()
+	prefs.SetString("dockerendpoints", `[{"name":`)
+
+	err := LoadDockerEndpoints(prefs)
+	if err == nil {
+		t.Fatal("LoadDockerEndpoints() should fail on a corrupt config")
+	}
+
+	err = SetDockerEndpoint(DockerEndpoint{Name: "remote1", Host: "tcp://10.0.0.1:2375"}, prefs)
+	if err == nil {
+		t.Error("SetDockerEndpoint() should fail while the config is corrupt")
+	}
+	if prefs.String("dockerendpoints") != `[{"name":` {
+		t.Error("a corrupt config should not be saved over")
+	}
+}
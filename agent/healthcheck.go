@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	dockerContainer "github.com/docker/docker/api/types/container"
+)
+
+const (
+	// HealthCheckNone turns off any healthcheck from the agent image.
+	HealthCheckNone = "none"
+	// HealthCheckProcess checks the agent process is running.
+	HealthCheckProcess = "process"
+	// HealthCheckUDP sends a server state query to the game port and waits
+	// for a reply.
+	HealthCheckUDP = "udp"
+	// HealthCheckCommand runs a custom shell command in the container.
+	HealthCheckCommand = "command"
+
+	DefaultHealthCheck = HealthCheckProcess
+)
+
+var HealthChecks = []string{HealthCheckNone, HealthCheckProcess, HealthCheckUDP, HealthCheckCommand}
+
+const (
+	defaultHealthCheckInterval    = 30
+	defaultHealthCheckTimeout     = 10
+	defaultHealthCheckStartPeriod = 120
+	defaultHealthCheckRetries     = 3
+)
+
+const (
+	healthCheckProcessScript = "pgrep -f SSMAgent > /dev/null"
+	// The server answers lightweight queries on the game port, which is
+	// always 7777 inside the container since the port offset is only applied
+	// to the host ports, and host networking requires offset 0. The message
+	// is a Satisfactory lightweight query poll: magic, poll type, protocol
+	// version, an 8 byte cookie and the terminator. Containers created with
+	// an older script are reported by doctor as a health check mismatch.
+	healthCheckUDPScript = `exec 3<>/dev/udp/127.0.0.1/7777 && printf '\xd5\xf6\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x01' >&3 && timeout 2 head -c 1 <&3 > /dev/null`
+)
+
+// AgentHealthCheck is the docker healthcheck of an agent. Durations are in
+// seconds. An empty Type leaves the healthcheck of the agent image in place,
+// which is how agents created before health checks were added are stored.
+type AgentHealthCheck struct {
+	Type string `json:"type,omitempty" yaml:"type"`
+	// Command is the shell command run by the command health check.
+	Command     string `json:"command,omitempty" yaml:"command"`
+	Interval    int    `json:"interval,omitempty" yaml:"interval"`
+	Timeout     int    `json:"timeout,omitempty" yaml:"timeout"`
+	StartPeriod int    `json:"startPeriod,omitempty" yaml:"startPeriod"`
+	Retries     int    `json:"retries,omitempty" yaml:"retries"`
+}
+
+func (h AgentHealthCheck) String() string {
+	switch h.Type {
+	case "", HealthCheckNone:
+		return h.Type
+	case HealthCheckCommand:
+		return fmt.Sprintf("%s %q every %ds", h.Type, h.Command, h.Interval)
+	default:
+		return fmt.Sprintf("%s every %ds", h.Type, h.Interval)
+	}
+}
+
+// settleTime is how long a new container can take to report healthy with
+// this health check.
+func (h AgentHealthCheck) settleTime() time.Duration {
+	if h.Type == "" || h.Type == HealthCheckNone {
+		return 0
+	}
+	return time.Duration(h.StartPeriod+h.Interval*h.Retries) * time.Second
+}
+
+// validateHealthCheck checks the health check of a docker agent and fills in
+// the default timings.
+func validateHealthCheck(agent *Agent) error {
+	healthCheck := &agent.HealthCheck
+
+	switch healthCheck.Type {
+	case "", HealthCheckNone:
+		*healthCheck = AgentHealthCheck{Type: healthCheck.Type}
+		return nil
+	case HealthCheckProcess, HealthCheckUDP:
+		if healthCheck.Command != "" {
+			return errors.New("a health check command can only be used with the command health check")
+		}
+	case HealthCheckCommand:
+		if strings.TrimSpace(healthCheck.Command) == "" {
+			return errors.New("the command health check needs a command")
+		}
+	default:
+		return errors.New("unknown health check " + healthCheck.Type + ", use one of " + strings.Join(HealthChecks, ", "))
+	}
+
+	if healthCheck.Interval < 0 || healthCheck.Timeout < 0 || healthCheck.StartPeriod < 0 || healthCheck.Retries < 0 {
+		return errors.New("health check timings can not be negative")
+	}
+
+	if healthCheck.Interval == 0 {
+		healthCheck.Interval = defaultHealthCheckInterval
+	}
+	if healthCheck.Timeout == 0 {
+		healthCheck.Timeout = defaultHealthCheckTimeout
+	}
+	if healthCheck.StartPeriod == 0 {
+		healthCheck.StartPeriod = defaultHealthCheckStartPeriod
+	}
+	if healthCheck.Retries == 0 {
+		healthCheck.Retries = defaultHealthCheckRetries
+	}
+
+	if healthCheck.Timeout > healthCheck.Interval {
+		return errors.New("health check timeout can not be longer than the interval")
+	}
+	return nil
+}
+
+// getDockerHealthConfig returns the docker healthcheck for the agent, or nil
+// to keep the one from the image.
+func getDockerHealthConfig(agent *Agent) *dockerContainer.HealthConfig {
+	healthCheck := agent.HealthCheck
+
+	var test []string
+	switch healthCheck.Type {
+	case "":
+		return nil
+	case HealthCheckNone:
+		return &dockerContainer.HealthConfig{Test: []string{"NONE"}}
+	case HealthCheckProcess:
+		test = []string{"CMD-SHELL", healthCheckProcessScript}
+	case HealthCheckUDP:
+		test = []string{"CMD", "bash", "-c", healthCheckUDPScript}
+	case HealthCheckCommand:
+		test = []string{"CMD-SHELL", healthCheck.Command}
+	}
+
+	return &dockerContainer.HealthConfig{
+		Test:        test,
+		Interval:    time.Duration(healthCheck.Interval) * time.Second,
+		Timeout:     time.Duration(healthCheck.Timeout) * time.Second,
+		StartPeriod: time.Duration(healthCheck.StartPeriod) * time.Second,
+		Retries:     healthCheck.Retries,
+	}
+}
+
+// getAgentHealthCheck reads the agent health check back from a container
+// config. Healthchecks that were not set by the manager are read as custom
+// commands.
+func getAgentHealthCheck(config *dockerContainer.HealthConfig) AgentHealthCheck {
+	if config == nil || len(config.Test) == 0 {
+		return AgentHealthCheck{}
+	}
+
+	healthCheck := AgentHealthCheck{
+		Interval:    int(config.Interval / time.Second),
+		Timeout:     int(config.Timeout / time.Second),
+		StartPeriod: int(config.StartPeriod / time.Second),
+		Retries:     config.Retries,
+	}
+
+	test := strings.Join(config.Test, " ")
+	switch {
+	case config.Test[0] == "NONE":
+		return AgentHealthCheck{Type: HealthCheckNone}
+	case test == "CMD-SHELL "+healthCheckProcessScript:
+		healthCheck.Type = HealthCheckProcess
+	case test == "CMD bash -c "+healthCheckUDPScript:
+		healthCheck.Type = HealthCheckUDP
+	default:
+		healthCheck.Type = HealthCheckCommand
+		healthCheck.Command = strings.TrimPrefix(strings.TrimPrefix(test, "CMD-SHELL "), "CMD ")
+	}
+
+	return healthCheck
+}
+
+// RefreshAgentHealth stores the live health of every agent on the agent
+// record, saving the inventory if any of them changed. Agents that are not
+// running or have no healthcheck have no health.
+func RefreshAgentHealth(prefs fyne.Preferences) {
+	changed := false
+	for idx := range AllAgents.Agents {
+		agent := &AllAgents.Agents[idx]
+
+		backend, err := GetAgentBackend(agent.AgentType)
+		if err != nil {
+			continue
+		}
+
+		health := ""
+		status, err := backend.Status(agent)
+		if err == nil && status.Running {
+			health = status.Health
+		}
+
+		if agent.Health != health {
+			agent.Health = health
+			changed = true
+		}
+	}
+
+	if changed {
+		SaveAgents(prefs)
+	}
+}
@@ -0,0 +1,22 @@
+package agent
+
+import "testing"
+
+func TestDockerHealthConfigRoundTrip(t *testing.T) {
+	tests := []AgentHealthCheck{
+		{},
+		{Type: HealthCheckNone},
+		{Type: HealthCheckProcess, Interval: 30, Timeout: 10, StartPeriod: 120, Retries: 3},
+		{Type: HealthCheckUDP, Interval: 30, Timeout: 10, StartPeriod: 120, Retries: 3},
+		{Type: HealthCheckCommand, Command: "test -f /tmp/ready", Interval: 60, Timeout: 5, StartPeriod: 30, Retries: 2},
+	}
+
+	for _, healthCheck := range tests {
+		t.Run(healthCheck.Type, func(t *testing.T) {
+			agent := &Agent{HealthCheck: healthCheck}
+			if got := getAgentHealthCheck(getDockerHealthConfig(agent)); got != healthCheck {
+				t.Errorf("getAgentHealthCheck() = %+v, want %+v", got, healthCheck)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+)
+
+// DefaultAgentImage is the image used when the manager has no default image
+// configured.
+const DefaultAgentImage = "docker.io/mrhid6/ssmagent:latest"
+
+// GetDefaultAgentImage returns the image new docker agents use when they are
+// not given one.
+func GetDefaultAgentImage(prefs fyne.Preferences) string {
+	return prefs.StringWithFallback("dockerimage", DefaultAgentImage)
+}
+
+// SetDefaultAgentImage saves the image new docker agents use, after checking
+// it is a valid image reference.
+func SetDefaultAgentImage(prefs fyne.Preferences, image string) error {
+	err := ValidateImageReference(image)
+	if err != nil {
+		return err
+	}
+
+	prefs.SetString("dockerimage", image)
+	return nil
+}
+
+// ValidateImageReference checks an image reference looks like
+// repository[:tag] or repository@digest.
+func ValidateImageReference(image string) error {
+	if image == "" {
+		return errors.New("agent image can not be empty")
+	}
+
+	if strings.ContainsAny(image, " \t\r\n") {
+		return errors.New("agent image can not contain spaces")
+	}
+
+	repository, digest, hasDigest := strings.Cut(image, "@")
+	if hasDigest && !strings.HasPrefix(digest, "sha256:") {
+		return errors.New("agent image digest must start with sha256:")
+	}
+
+	if repository == "" || strings.HasSuffix(repository, ":") || strings.HasSuffix(repository, "/") {
+		return errors.New("agent image " + image + " is not a valid image reference")
+	}
+
+	return nil
+}
+
+// getImageRepository returns the image reference without its tag or digest.
+func getImageRepository(image string) string {
+	repository, _, _ := strings.Cut(image, "@")
+
+	// A colon after the last slash is the tag, one before it is a registry
+	// port.
+	if idx := strings.LastIndex(repository, ":"); idx > strings.LastIndex(repository, "/") {
+		repository = repository[:idx]
+	}
+
+	return strings.TrimPrefix(repository, "docker.io/")
+}
+
+// isSameImage compares two image references, ignoring the default docker.io
+// registry and latest tag.
+func isSameImage(a string, b string) bool {
+	return normalizeImageReference(a) == normalizeImageReference(b)
+}
+
+func normalizeImageReference(image string) string {
+	image = strings.TrimPrefix(image, "docker.io/")
+	image = strings.TrimPrefix(image, "library/")
+
+	if !strings.Contains(image, "@") && getImageRepository(image) == image {
+		image += ":latest"
+	}
+	return image
+}
+
+// getRegistryAuth returns the encoded registry credentials from the manager
+// config, or an empty string if none are set.
+func getRegistryAuth(prefs fyne.Preferences) (string, error) {
+	username := prefs.String("dockerregistryusername")
+	if username == "" {
+		return "", nil
+	}
+
+	return registry.EncodeAuthConfig(registry.AuthConfig{
+		Username: username,
+		Password: prefs.String("dockerregistrypassword"),
+	})
+}
+
+// getImageDigest returns the repo digest of a local image matching the agent
+// image repository, falling back to the image id.
+func getImageDigest(ctx context.Context, cli *client.Client, imageID string, image string) string {
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, imageID)
+	if err != nil {
+		return imageID
+	}
+
+	repository := getImageRepository(image)
+	for _, repoDigest := range inspect.RepoDigests {
+		if getImageRepository(repoDigest) == repository {
+			return repoDigest
+		}
+	}
+
+	if len(inspect.RepoDigests) > 0 {
+		return inspect.RepoDigests[0]
+	}
+	return imageID
+}
@@ -0,0 +1,31 @@
+package agent
+
+import "testing"
+
+func TestValidateImageReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		wantErr bool
+	}{
+		{image: "mrhid6/ssmagent"},
+		{image: "mrhid6/ssmagent:v1.2"},
+		{image: "docker.io/mrhid6/ssmagent:latest"},
+		{image: "registry.example.com:5000/ssmagent:v1"},
+		{image: "mrhid6/ssmagent@sha256:0123456789abcdef"},
+		{image: "", wantErr: true},
+		{image: "mrhid6/ssm agent", wantErr: true},
+		{image: "mrhid6/ssmagent@md5:0123", wantErr: true},
+		{image: "mrhid6/ssmagent:", wantErr: true},
+		{image: "mrhid6/", wantErr: true},
+		{image: "@sha256:0123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			err := ValidateImageReference(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateImageReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
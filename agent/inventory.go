@@ -0,0 +1,246 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	InventoryVersion = 1
+	redactedSecret   = "REDACTED"
+
+	ImportMerge   = "merge"
+	ImportReplace = "replace"
+)
+
+// InventoryDocument is the exported agent inventory and manager config.
+type InventoryDocument struct {
+	Version    int           `json:"version"`
+	ExportedAt time.Time     `json:"exportedAt"`
+	Config     ManagerConfig `json:"config"`
+	Agents     []Agent       `json:"agents"`
+}
+
+type ManagerConfig struct {
+	SSMURL                 string           `json:"ssmUrl"`
+	SSMAPIKey              string           `json:"ssmApiKey"`
+	DockerImage            string           `json:"dockerImage,omitempty"`
+	DockerRegistryUsername string           `json:"dockerRegistryUsername,omitempty"`
+	DockerRegistryPassword string           `json:"dockerRegistryPassword,omitempty"`
+	DockerEndpoints        []DockerEndpoint `json:"dockerEndpoints,omitempty"`
+}
+
+// ExportInventory writes the inventory as JSON or YAML. Api keys are
+// redacted unless includeSecrets is set.
+func ExportInventory(prefs fyne.Preferences, format string, includeSecrets bool) ([]byte, error) {
+	doc := InventoryDocument{
+		Version:    InventoryVersion,
+		ExportedAt: time.Now().UTC(),
+		Config: ManagerConfig{
+			SSMURL:    utils.GetSSMURL(prefs),
+			SSMAPIKey: prefs.String("ssmapikey"),
+
+			DockerImage:            GetDefaultAgentImage(prefs),
+			DockerRegistryUsername: prefs.String("dockerregistryusername"),
+			DockerRegistryPassword: prefs.String("dockerregistrypassword"),
+			DockerEndpoints:        AllDockerEndpoints,
+		},
+		Agents: append([]Agent{}, AllAgents.Agents...),
+	}
+
+	if !includeSecrets {
+		doc.Config.SSMAPIKey = redactSecret(doc.Config.SSMAPIKey)
+		doc.Config.DockerRegistryPassword = redactSecret(doc.Config.DockerRegistryPassword)
+		for idx := range doc.Agents {
+			doc.Agents[idx].APIKey = redactSecret(doc.Agents[idx].APIKey)
+		}
+	}
+
+	b, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		return b, nil
+	case "yaml", "yml":
+		// Go through JSON so the YAML keys match the JSON field names.
+		var generic interface{}
+		err = json.Unmarshal(b, &generic)
+		if err != nil {
+			return nil, err
+		}
+		return yaml.Marshal(generic)
+	default:
+		return nil, errors.New("unknown export format " + format)
+	}
+}
+
+// ParseInventory reads an exported JSON or YAML inventory document.
+func ParseInventory(b []byte) (*InventoryDocument, error) {
+	var generic interface{}
+	err := yaml.Unmarshal(b, &generic)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBytes, err := json.Marshal(generic)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &InventoryDocument{}
+	err = json.Unmarshal(jsonBytes, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.Version != InventoryVersion {
+		return nil, fmt.Errorf("unsupported inventory version %d", doc.Version)
+	}
+
+	return doc, nil
+}
+
+// ImportInventory loads an inventory document into the manager. Merge adds
+// the agents to the existing inventory, replace swaps the inventory for the
+// agents in the document. Only the records are imported, nothing is
+// installed or removed.
+func ImportInventory(doc *InventoryDocument, mode string, prefs fyne.Preferences) error {
+	if mode != ImportMerge && mode != ImportReplace {
+		return errors.New("unknown import mode " + mode)
+	}
+
+	agents := []Agent{}
+	if mode == ImportMerge {
+		agents = append(agents, AllAgents.Agents...)
+	}
+
+	conflicts := []string{}
+	installed := map[string][]Agent{}
+	for _, imported := range doc.Agents {
+		if imported.APIKey == redactedSecret {
+			imported.APIKey = recoverAgentAPIKey(&imported, installed)
+			if imported.APIKey == "" {
+				conflicts = append(conflicts, fmt.Sprintf("agent %s has a redacted api key that could not be read from the installed agent, export it with --include-secrets", imported.Name))
+			}
+		}
+
+		for _, existing := range agents {
+			if existing.Name == imported.Name {
+				conflicts = append(conflicts, fmt.Sprintf("agent %s already exists", imported.Name))
+			} else if existing.PortOffset == imported.PortOffset && existing.Endpoint == imported.Endpoint {
+				conflicts = append(conflicts, fmt.Sprintf("agent %s uses the same port offset %d as agent %s", imported.Name, imported.PortOffset, existing.Name))
+			}
+		}
+
+		if !isLocalEndpoint(imported.Endpoint) && !hasDockerEndpoint(doc.Config.DockerEndpoints, imported.Endpoint) {
+			if _, err := GetDockerEndpoint(imported.Endpoint); err != nil {
+				conflicts = append(conflicts, fmt.Sprintf("agent %s uses docker endpoint %s which does not exist", imported.Name, imported.Endpoint))
+			}
+		}
+
+		agents = append(agents, imported)
+	}
+
+	if len(conflicts) > 0 {
+		return errors.New("import conflicts: " + strings.Join(conflicts, "; "))
+	}
+
+	AllAgents.Agents = agents
+	SaveAgents(prefs)
+
+	if utils.SkipForDryRun("save manager config") {
+		return nil
+	}
+
+	if doc.Config.SSMURL != "" {
+		prefs.SetString("ssmurl", doc.Config.SSMURL)
+	}
+
+	if doc.Config.SSMAPIKey != "" && doc.Config.SSMAPIKey != redactedSecret {
+		prefs.SetString("ssmapikey", doc.Config.SSMAPIKey)
+		prefs.SetBool("testedconnection", false)
+	}
+
+	if doc.Config.DockerImage != "" {
+		prefs.SetString("dockerimage", doc.Config.DockerImage)
+	}
+
+	if doc.Config.DockerRegistryUsername != "" {
+		prefs.SetString("dockerregistryusername", doc.Config.DockerRegistryUsername)
+	}
+
+	if doc.Config.DockerRegistryPassword != "" && doc.Config.DockerRegistryPassword != redactedSecret {
+		prefs.SetString("dockerregistrypassword", doc.Config.DockerRegistryPassword)
+	}
+
+	for _, endpoint := range doc.Config.DockerEndpoints {
+		err := SetDockerEndpoint(endpoint, prefs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recoverAgentAPIKey finds the api key of an agent exported without secrets,
+// from the current inventory or else from the installed agent like discover
+// does. installed caches the discovered agents of each agent type. An empty
+// key is returned when it can not be found.
+func recoverAgentAPIKey(imported *Agent, installed map[string][]Agent) string {
+	for _, existing := range AllAgents.Agents {
+		if existing.Name == imported.Name && existing.AgentType == imported.AgentType && existing.APIKey != "" {
+			return existing.APIKey
+		}
+	}
+
+	discovered, ok := installed[imported.AgentType]
+	if !ok {
+		backend, err := GetAgentBackend(imported.AgentType)
+		if err != nil {
+			return ""
+		}
+
+		// Agents found before a scan error are still searched.
+		discovered, err = backend.Discover()
+		if err != nil {
+			log.Printf("Error discovering %s agents, with error %s\r\n", imported.AgentType, err.Error())
+		}
+		installed[imported.AgentType] = discovered
+	}
+
+	for _, agent := range discovered {
+		sameAgent := agent.Name == imported.Name || (imported.DockerID != "" && agent.DockerID == imported.DockerID)
+		if sameAgent && agent.APIKey != "" {
+			return agent.APIKey
+		}
+	}
+	return ""
+}
+
+func hasDockerEndpoint(endpoints []DockerEndpoint, name string) bool {
+	for _, endpoint := range endpoints {
+		if endpoint.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedSecret
+}
@@ -0,0 +1,62 @@
+package agent
+
+import "testing"
+
+func TestImportInventoryRedactedAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   []Agent
+		discovered []Agent
+		mode       string
+		wantKey    string
+		wantErr    bool
+	}{
+		{
+			name:       "key read from the installed agent",
+			discovered: []Agent{{Name: "agent1", AgentType: "fake", APIKey: "AGT-API-1"}},
+			mode:       ImportMerge,
+			wantKey:    "AGT-API-1",
+		},
+		{
+			name:     "key kept from the replaced record",
+			existing: []Agent{{Name: "agent1", AgentType: "fake", APIKey: "AGT-API-2"}},
+			mode:     ImportReplace,
+			wantKey:  "AGT-API-2",
+		},
+		{
+			name:       "key that can not be found is refused",
+			discovered: []Agent{{Name: "agent2", AgentType: "fake", APIKey: "AGT-API-3"}},
+			mode:       ImportMerge,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{discovered: tt.discovered}
+			_, prefs := useFakeBackend(t, backend, StateStopped)
+			AllAgents = Agents{Agents: tt.existing}
+
+			doc := &InventoryDocument{
+				Version: InventoryVersion,
+				Agents:  []Agent{{Name: "agent1", AgentType: "fake", PortOffset: 1, APIKey: redactedSecret}},
+			}
+
+			err := ImportInventory(doc, tt.mode, prefs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ImportInventory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			imported, err := GetAgent("agent1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if imported.APIKey != tt.wantKey {
+				t.Errorf("api key = %q, want %q", imported.APIKey, tt.wantKey)
+			}
+		})
+	}
+}
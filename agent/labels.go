@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Labels set on every container created by the agent manager. Docker labels
+// can not be changed, so the agent name and port offset are the values the
+// container was created with.
+const (
+	LabelManagedBy      = "io.ssm.managed-by"
+	LabelAgentName      = "io.ssm.agent.name"
+	LabelAgentType      = "io.ssm.agent.type"
+	LabelManagerVersion = "io.ssm.manager.version"
+	LabelPortOffset     = "io.ssm.agent.port-offset"
+
+	ManagedByValue = "ssm-agent-manager"
+)
+
+// ErrContainerNotManaged is returned when the agent manager is asked to remove
+// or replace a container it does not own.
+var ErrContainerNotManaged = errors.New("container was not created by the agent manager")
+
+func getDockerAgentLabels(agent *Agent) map[string]string {
+	return map[string]string{
+		LabelManagedBy:      ManagedByValue,
+		LabelAgentName:      agent.Name,
+		LabelAgentType:      agent.AgentType,
+		LabelManagerVersion: utils.Version,
+		LabelPortOffset:     strconv.Itoa(agent.PortOffset),
+	}
+}
+
+func isManagedContainer(labels map[string]string) bool {
+	return labels[LabelManagedBy] == ManagedByValue
+}
+
+// isOwnedContainer reports whether the container the agent record points at
+// is labelled by the manager. Containers created before labels were added
+// are only owned when they run the ssmagent image and are named after the
+// agent, as the manager created them.
+func isOwnedContainer(agent *Agent, containerName string, image string, labels map[string]string) bool {
+	if isManagedContainer(labels) {
+		return true
+	}
+
+	return getImageRepository(image) == getImageRepository(DefaultAgentImage) &&
+		strings.TrimPrefix(containerName, "/") == agent.Name
+}
+
+func getManagedContainerFilter() filters.Args {
+	return filters.NewArgs(filters.Arg("label", LabelManagedBy+"="+ManagedByValue))
+}
+
+// CheckDockerContainerManaged returns ErrContainerNotManaged if the agent
+// does not own its container, see isOwnedContainer. Replacing an unlabelled
+// container that was created before labels were added adds the labels.
+func CheckDockerContainerManaged(agent *Agent) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	inspect, err := cli.ContainerInspect(ctx, agent.DockerID)
+	if err != nil {
+		return err
+	}
+
+	if !isOwnedContainer(agent, inspect.Name, inspect.Config.Image, inspect.Config.Labels) {
+		return fmt.Errorf("docker container %s: %w", agent.Name, ErrContainerNotManaged)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package agent
+
+import "testing"
+
+func TestIsOwnedContainer(t *testing.T) {
+	managed := map[string]string{LabelManagedBy: ManagedByValue}
+	other := map[string]string{"com.example": "value"}
+
+	tests := []struct {
+		name    string
+		ctrName string
+		image   string
+		labels  map[string]string
+		want    bool
+	}{
+		{name: "labelled container", ctrName: "/other", image: "nginx", labels: managed, want: true},
+		{name: "legacy container", ctrName: "/agent1", image: "mrhid6/ssmagent:latest", labels: other, want: true},
+		{name: "legacy container with a tag", ctrName: "/agent1", image: "docker.io/mrhid6/ssmagent:v1", labels: nil, want: true},
+		{name: "legacy container with another image", ctrName: "/agent1", image: "nginx:latest", labels: nil, want: false},
+		{name: "legacy container with another name", ctrName: "/web", image: "mrhid6/ssmagent:latest", labels: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := &Agent{Name: "agent1"}
+			if got := isOwnedContainer(agent, tt.ctrName, tt.image, tt.labels); got != tt.want {
+				t.Errorf("isOwnedContainer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
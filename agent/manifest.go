@@ -0,0 +1,295 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// Manifest describes the agents that should exist. It is read from YAML, and
+// JSON manifests work as well since JSON is valid YAML.
+type Manifest struct {
+	Agents []ManifestAgent `yaml:"agents"`
+}
+
+type ManifestAgent struct {
+	Name       string `yaml:"name"`
+	Type       string `yaml:"type"`
+	PortOffset int    `yaml:"portOffset"`
+	// Memory is the docker memory limit in GB.
+	Memory int     `yaml:"memory"`
+	CPUs   float64 `yaml:"cpus"`
+	CPUSet string  `yaml:"cpuset"`
+	// MemoryReservation is the docker soft memory limit in GB.
+	MemoryReservation int   `yaml:"memoryReservation"`
+	PidsLimit         int64 `yaml:"pidsLimit"`
+	// RestartPolicy is the docker restart policy, unless-stopped when empty.
+	RestartPolicy string `yaml:"restartPolicy"`
+	// Image is the docker image reference, the manager default when empty.
+	Image string `yaml:"image"`
+	// Network is the docker networking, the default bridge when empty.
+	Network AgentNetwork `yaml:"network"`
+	// HealthCheck is the docker health check. New agents use the process
+	// check when it is empty, existing agents keep their health check.
+	HealthCheck AgentHealthCheck `yaml:"healthCheck"`
+	// Endpoint is the docker endpoint the agent is placed on, the local
+	// docker host when empty.
+	Endpoint string `yaml:"endpoint"`
+	DataDir  string `yaml:"dataDir"`
+}
+
+func (m *ManifestAgent) resources() AgentResources {
+	return AgentResources{
+		CPUs:              m.CPUs,
+		CPUSet:            m.CPUSet,
+		MemoryReservation: m.MemoryReservation,
+		PidsLimit:         m.PidsLimit,
+	}
+}
+
+func (m *ManifestAgent) createOptions() CreateAgentOptions {
+	return CreateAgentOptions{
+		Resources:     m.resources(),
+		RestartPolicy: m.RestartPolicy,
+		Image:         m.Image,
+		Network:       m.Network,
+		HealthCheck:   m.HealthCheck,
+		Endpoint:      m.Endpoint,
+	}
+}
+
+// ManifestChange is a single change needed to make the inventory match a
+// manifest.
+type ManifestChange struct {
+	Action   string
+	Agent    string
+	Details  []string
+	Warnings []string
+
+	manifestAgent ManifestAgent
+	updateOptions UpdateAgentOptions
+}
+
+func LoadManifest(manifestPath string) (*Manifest, error) {
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	err = yaml.Unmarshal(b, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for idx := range manifest.Agents {
+		manifestAgent := &manifest.Agents[idx]
+		manifestAgent.Type = strings.ToLower(manifestAgent.Type)
+
+		err := validateAgentName(manifestAgent.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if names[manifestAgent.Name] {
+			return nil, fmt.Errorf("agent %s is in the manifest more than once", manifestAgent.Name)
+		}
+		names[manifestAgent.Name] = true
+	}
+
+	return manifest, nil
+}
+
+// PlanManifest works out the changes needed to make the inventory match the
+// manifest. Agents that are not in the manifest are only deleted when prune
+// is set. New agents are checked by their backend so invalid settings are
+// reported by the plan rather than part way through an apply.
+func PlanManifest(manifest *Manifest, prune bool, prefs fyne.Preferences) ([]ManifestChange, error) {
+	changes := []ManifestChange{}
+
+	for _, manifestAgent := range manifest.Agents {
+		existing, err := GetAgent(manifestAgent.Name)
+		if err != nil {
+			_, _, err := newAgentRecord(
+				manifestAgent.Name,
+				manifestAgent.Type,
+				manifestAgent.PortOffset,
+				manifestAgent.Memory,
+				manifestAgent.DataDir,
+				manifestAgent.createOptions(),
+				prefs,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("agent %s is not valid: %w", manifestAgent.Name, err)
+			}
+
+			changes = append(changes, ManifestChange{
+				Action:        ChangeCreate,
+				Agent:         manifestAgent.Name,
+				Details:       []string{fmt.Sprintf("%s agent with port offset %d", manifestAgent.Type, manifestAgent.PortOffset)},
+				manifestAgent: manifestAgent,
+			})
+			continue
+		}
+
+		if existing.AgentType != manifestAgent.Type {
+			return nil, fmt.Errorf("agent %s is a %s agent, changing it to %s is not supported", existing.Name, existing.AgentType, manifestAgent.Type)
+		}
+
+		if normalizeEndpointName(existing.Endpoint) != normalizeEndpointName(manifestAgent.Endpoint) {
+			return nil, fmt.Errorf("agent %s is on docker endpoint %s, moving it to %s is not supported", existing.Name, GetAgentHost(existing), manifestAgent.Endpoint)
+		}
+
+		opts := UpdateAgentOptions{}
+		if existing.PortOffset != manifestAgent.PortOffset {
+			portOffset := manifestAgent.PortOffset
+			opts.PortOffset = &portOffset
+		}
+
+		caps := existing.Capabilities()
+		if caps.Container && existing.Memory != manifestAgent.Memory*1024*1024*1024 {
+			memory := manifestAgent.Memory
+			opts.Memory = &memory
+		}
+
+		if caps.Container {
+			resources := manifestAgent.resources()
+			if existing.Resources.CPUs != resources.CPUs {
+				opts.CPUs = &resources.CPUs
+			}
+			if existing.Resources.CPUSet != resources.CPUSet {
+				opts.CPUSet = &resources.CPUSet
+			}
+			if existing.Resources.MemoryReservation != resources.MemoryReservation*1024*1024*1024 {
+				opts.MemoryReservation = &resources.MemoryReservation
+			}
+			if existing.Resources.PidsLimit != resources.PidsLimit {
+				opts.PidsLimit = &resources.PidsLimit
+			}
+
+			restartPolicy := manifestAgent.RestartPolicy
+			if restartPolicy == "" {
+				restartPolicy = DefaultRestartPolicy
+			}
+			if existing.RestartPolicy != restartPolicy {
+				opts.RestartPolicy = &restartPolicy
+			}
+
+			image := manifestAgent.Image
+			if image != "" && !isSameImage(existing.Image, image) {
+				opts.Image = &image
+			}
+
+			network := manifestAgent.Network
+			if network.GetMode() == NetworkUser && network.Name == "" {
+				network.Name = DefaultUserNetworkName
+			}
+			if existing.Network.GetMode() != network.GetMode() ||
+				existing.Network.Name != network.Name ||
+				existing.Network.BindIP != network.BindIP {
+				opts.NetworkMode = &network.Mode
+				opts.NetworkName = &network.Name
+				opts.BindIP = &network.BindIP
+			}
+
+			if manifestAgent.HealthCheck.Type != "" {
+				// Fill in the default timings before comparing, invalid
+				// health checks are passed on for PlanAgentUpdate to report.
+				normalized := Agent{HealthCheck: manifestAgent.HealthCheck}
+				err := validateHealthCheck(&normalized)
+				if err != nil || existing.HealthCheck != normalized.HealthCheck {
+					opts.HealthCheck = &normalized.HealthCheck
+				}
+			}
+		}
+
+		plan, err := PlanAgentUpdate(existing.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(plan.Changes) == 0 {
+			continue
+		}
+
+		changes = append(changes, ManifestChange{
+			Action:        ChangeUpdate,
+			Agent:         existing.Name,
+			Details:       plan.Changes,
+			Warnings:      plan.Warnings,
+			updateOptions: opts,
+		})
+	}
+
+	if !prune {
+		return changes, nil
+	}
+
+	for _, existing := range AllAgents.Agents {
+		if manifestHasAgent(manifest, existing.Name) {
+			continue
+		}
+
+		changes = append(changes, ManifestChange{
+			Action:   ChangeDelete,
+			Agent:    existing.Name,
+			Details:  []string{"agent is not in the manifest"},
+			Warnings: []string{"the agent and its data will be removed"},
+		})
+	}
+
+	return changes, nil
+}
+
+// ApplyManifestChanges carries out planned changes using the normal create,
+// update and delete operations. It stops at the first change that fails.
+func ApplyManifestChanges(changes []ManifestChange, prefs fyne.Preferences) error {
+	for _, change := range changes {
+		var err error
+
+		switch change.Action {
+		case ChangeCreate:
+			_, err = CreateNewAgent(
+				change.manifestAgent.Name,
+				change.manifestAgent.Type,
+				change.manifestAgent.PortOffset,
+				change.manifestAgent.Memory,
+				change.manifestAgent.DataDir,
+				change.manifestAgent.createOptions(),
+				prefs,
+			)
+		case ChangeUpdate:
+			err = UpdateAgent(change.Agent, change.updateOptions, prefs)
+		case ChangeDelete:
+			err = DeleteAgent(change.Agent, DeleteAgentOptions{}, prefs)
+		default:
+			err = errors.New("unknown change " + change.Action)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%s agent %s failed: %w", change.Action, change.Agent, err)
+		}
+	}
+
+	return nil
+}
+
+func manifestHasAgent(manifest *Manifest, name string) bool {
+	for _, manifestAgent := range manifest.Agents {
+		if manifestAgent.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"testing"
+)
+
+func TestPlanManifestCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		agent   ManifestAgent
+		wantErr bool
+	}{
+		{name: "valid docker agent", agent: ManifestAgent{Name: "new1", Type: "docker", Memory: 4}},
+		{name: "docker agent without memory", agent: ManifestAgent{Name: "new1", Type: "docker"}, wantErr: true},
+		{name: "invalid image", agent: ManifestAgent{Name: "new1", Type: "docker", Memory: 4, Image: "Bad Image"}, wantErr: true},
+		{name: "unknown type", agent: ManifestAgent{Name: "new1", Type: "vm"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, prefs := useFakeBackend(t, &fakeBackend{}, StateRunning)
+
+			manifest := &Manifest{Agents: []ManifestAgent{tt.agent}}
+			changes, err := PlanManifest(manifest, false, prefs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlanManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(changes) != 1 || changes[0].Action != ChangeCreate {
+				t.Errorf("changes = %v, want a single create", changes)
+			}
+			if len(AllAgents.Agents) != 1 {
+				t.Errorf("planning added %d agents to the inventory", len(AllAgents.Agents)-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"github.com/docker/docker/api/types"
+	dockerContainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// NetworkBridge publishes the agent ports from the default docker
+	// bridge.
+	NetworkBridge = "bridge"
+	// NetworkHost runs the agent on the host network without NAT.
+	NetworkHost = "host"
+	// NetworkUser publishes the agent ports from a user defined bridge
+	// network, which is created if it does not exist.
+	NetworkUser = "user"
+	// NetworkExternal attaches the agent to an existing network that is
+	// managed outside the agent manager.
+	NetworkExternal = "external"
+
+	DefaultUserNetworkName = "ssm-agents"
+)
+
+var NetworkModes = []string{NetworkBridge, NetworkHost, NetworkUser, NetworkExternal}
+
+// AgentNetwork is the networking of an agent. The zero value publishes the
+// ports on all host addresses from the default bridge.
+type AgentNetwork struct {
+	Mode string `json:"mode,omitempty" yaml:"mode"`
+	// Name is the docker network used by the user and external modes.
+	Name string `json:"name,omitempty" yaml:"name"`
+	// BindIP is the host address the agent ports are published on.
+	BindIP string `json:"bindIp,omitempty" yaml:"bindIp"`
+}
+
+func (n AgentNetwork) GetMode() string {
+	if n.Mode == "" {
+		return NetworkBridge
+	}
+	return n.Mode
+}
+
+func (n AgentNetwork) String() string {
+	description := n.GetMode()
+	if n.Name != "" {
+		description += " " + n.Name
+	}
+	if n.BindIP != "" {
+		description += " on " + n.BindIP
+	}
+	return description
+}
+
+// validateDockerNetwork checks the network settings of a docker agent,
+// normalises the bridge mode and fills in the default user network name.
+func validateDockerNetwork(agent *Agent) error {
+	network := &agent.Network
+
+	if network.BindIP != "" && net.ParseIP(network.BindIP) == nil {
+		return errors.New("agent bind ip " + network.BindIP + " is not an ip address")
+	}
+
+	switch network.GetMode() {
+	case NetworkBridge:
+		// The zero value is the bridge, so store it that way to compare
+		// networks field by field.
+		network.Mode = ""
+		if network.Name != "" {
+			return errors.New("a network name can only be used with the user or external network modes")
+		}
+	case NetworkHost:
+		if network.Name != "" || network.BindIP != "" {
+			return errors.New("host networking can not be used with a network name or bind ip")
+		}
+
+		// Ports are not remapped on the host network, so the container
+		// listens on the default ports.
+		if agent.PortOffset != 0 {
+			return errors.New("host networking can only be used with port offset 0")
+		}
+	case NetworkUser:
+		if network.Name == "" {
+			network.Name = DefaultUserNetworkName
+		}
+	case NetworkExternal:
+		if network.Name == "" {
+			return errors.New("the external network mode needs a network name")
+		}
+	default:
+		return errors.New("unknown network mode " + network.Mode)
+	}
+
+	return nil
+}
+
+// validateStandaloneNetwork checks the network settings of a standalone
+// agent, which always runs on the host network. The bind ip is passed to the
+// server as its multihome address.
+func validateStandaloneNetwork(agent *Agent) error {
+	if agent.Network.GetMode() != NetworkHost && agent.Network.Mode != "" {
+		return errors.New("standalone agents always use the host network")
+	}
+
+	if agent.Network.Name != "" {
+		return errors.New("standalone agents can not use a network name")
+	}
+
+	if agent.Network.BindIP != "" && net.ParseIP(agent.Network.BindIP) == nil {
+		return errors.New("agent bind ip " + agent.Network.BindIP + " is not an ip address")
+	}
+	return nil
+}
+
+// getDockerNetworkMode returns the docker network mode for the agent.
+func getDockerNetworkMode(agent *Agent) dockerContainer.NetworkMode {
+	switch agent.Network.GetMode() {
+	case NetworkHost:
+		return "host"
+	case NetworkUser, NetworkExternal:
+		return dockerContainer.NetworkMode(agent.Network.Name)
+	default:
+		return "default"
+	}
+}
+
+// getAgentNetwork reads the agent network back from a container. Networks
+// that are not the default bridge or host are treated as external, since
+// they were not created by the agent manager.
+func getAgentNetwork(hostConfig *dockerContainer.HostConfig) AgentNetwork {
+	network := AgentNetwork{}
+
+	switch mode := hostConfig.NetworkMode; {
+	case mode.IsHost():
+		network.Mode = NetworkHost
+	case mode.IsDefault() || mode.IsBridge():
+	default:
+		network.Mode = NetworkExternal
+		network.Name = mode.NetworkName()
+	}
+
+	bindings := hostConfig.PortBindings["15777/udp"]
+	if len(bindings) > 0 && bindings[0].HostIP != "0.0.0.0" {
+		network.BindIP = bindings[0].HostIP
+	}
+
+	return network
+}
+
+// isSameNetwork compares the agent network with one read from a container,
+// which can not tell user and external networks apart.
+func isSameNetwork(agentNetwork AgentNetwork, containerNetwork AgentNetwork) bool {
+	if agentNetwork.GetMode() == NetworkUser {
+		agentNetwork.Mode = NetworkExternal
+	}
+
+	return agentNetwork.GetMode() == containerNetwork.GetMode() &&
+		agentNetwork.Name == containerNetwork.Name &&
+		agentNetwork.BindIP == containerNetwork.BindIP
+}
+
+// ensureDockerNetwork creates the user network of the agent if it does not
+// exist, and checks an external network exists and can be attached to.
+func ensureDockerNetwork(agent *Agent) error {
+	mode := agent.Network.GetMode()
+	if mode != NetworkUser && mode != NetworkExternal {
+		return nil
+	}
+
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	network, err := cli.NetworkInspect(ctx, agent.Network.Name, types.NetworkInspectOptions{})
+	if err == nil {
+		if mode == NetworkExternal && network.Scope == "swarm" && !network.Attachable {
+			return errors.New("docker network " + agent.Network.Name + " is not attachable")
+		}
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	if mode == NetworkExternal {
+		return errors.New("docker network " + agent.Network.Name + " does not exist")
+	}
+
+	if utils.SkipForDryRun("create docker network %s", agent.Network.Name) {
+		return nil
+	}
+
+	log.Printf("Creating docker network %s\r\n", agent.Network.Name)
+	_, err = cli.NetworkCreate(ctx, agent.Network.Name, types.NetworkCreate{
+		Driver:         "bridge",
+		CheckDuplicate: true,
+	})
+	return err
+}
@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PullProgress is a progress event for a single image layer, decoded from
+// the docker pull stream. Events without a layer are about the whole image.
+type PullProgress struct {
+	Layer   string
+	Status  string
+	Current int64
+	Total   int64
+}
+
+// InstallOptions changes how an agent is installed.
+type InstallOptions struct {
+	// NoPull uses the image that is already on the host instead of pulling
+	// it.
+	NoPull bool
+	// OnPullProgress is called for every event in the image pull stream.
+	OnPullProgress func(PullProgress)
+}
+
+// pullMessage is an event in the docker pull stream.
+type pullMessage struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress *struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+// PullProgressTracker adds up layer progress events into the progress of the
+// whole pull.
+type PullProgressTracker struct {
+	mu     sync.Mutex
+	layers map[string]PullProgress
+}
+
+func NewPullProgressTracker() *PullProgressTracker {
+	return &PullProgressTracker{layers: map[string]PullProgress{}}
+}
+
+func (t *PullProgressTracker) Update(progress PullProgress) {
+	// The "Pulling from" event uses the image tag as its id.
+	if progress.Layer == "" || strings.HasPrefix(progress.Status, "Pulling from") {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previous, ok := t.layers[progress.Layer]
+	if ok && progress.Total == 0 {
+		// Keep the byte counts of layers that moved on to extracting or
+		// finished, those events have no progress.
+		progress.Current = previous.Total
+		progress.Total = previous.Total
+	}
+	t.layers[progress.Layer] = progress
+}
+
+// Progress returns the fraction of the pull that is done, from 0 to 1, and
+// how many of the layers are complete.
+func (t *PullProgressTracker) Progress() (fraction float64, complete int, layers int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var current, total int64
+	for _, layer := range t.layers {
+		if isPullLayerComplete(layer.Status) {
+			complete++
+		}
+
+		current += layer.Current
+		total += layer.Total
+	}
+
+	layers = len(t.layers)
+	if layers > 0 && complete == layers {
+		return 1, complete, layers
+	}
+
+	// Docker can report more bytes than the layer total, for example while
+	// extracting, so the fraction is kept between 0 and 1.
+	if total > 0 {
+		fraction = math.Min(math.Max(float64(current)/float64(total), 0), 1)
+	}
+	return fraction, complete, layers
+}
+
+// Layers returns the progress of every layer, sorted by layer id.
+func (t *PullProgressTracker) Layers() []PullProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	layers := make([]PullProgress, 0, len(t.layers))
+	for _, layer := range t.layers {
+		layers = append(layers, layer)
+	}
+
+	sort.Slice(layers, func(i, j int) bool {
+		return layers[i].Layer < layers[j].Layer
+	})
+	return layers
+}
+
+func isPullLayerComplete(status string) bool {
+	return status == "Pull complete" || status == "Already exists"
+}
+
+// readPullStream reads the docker pull stream until it ends, passing every
+// event to onProgress. An error reported in the stream is returned.
+func readPullStream(reader io.Reader, onProgress func(PullProgress)) error {
+	decoder := json.NewDecoder(reader)
+
+	for {
+		var msg pullMessage
+		err := decoder.Decode(&msg)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+
+		if onProgress == nil {
+			continue
+		}
+
+		progress := PullProgress{
+			Layer:  msg.ID,
+			Status: msg.Status,
+		}
+
+		if msg.Progress != nil {
+			progress.Current = msg.Progress.Current
+			progress.Total = msg.Progress.Total
+		}
+
+		onProgress(progress)
+	}
+}
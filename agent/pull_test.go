@@ -0,0 +1,47 @@
+package agent
+
+import "testing"
+
+func TestPullProgressTrackerProgress(t *testing.T) {
+	tests := []struct {
+		name         string
+		events       []PullProgress
+		wantFraction float64
+		wantComplete int
+	}{
+		{name: "no layers", wantFraction: 0},
+		{
+			name:         "half downloaded",
+			events:       []PullProgress{{Layer: "a", Status: "Downloading", Current: 50, Total: 100}},
+			wantFraction: 0.5,
+		},
+		{
+			name:         "more bytes than the total",
+			events:       []PullProgress{{Layer: "a", Status: "Downloading", Current: 150, Total: 100}},
+			wantFraction: 1,
+		},
+		{
+			name: "all layers complete",
+			events: []PullProgress{
+				{Layer: "a", Status: "Downloading", Current: 10, Total: 100},
+				{Layer: "a", Status: "Pull complete"},
+			},
+			wantFraction: 1,
+			wantComplete: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewPullProgressTracker()
+			for _, event := range tt.events {
+				tracker.Update(event)
+			}
+
+			fraction, complete, _ := tracker.Progress()
+			if fraction != tt.wantFraction || complete != tt.wantComplete {
+				t.Errorf("Progress() = %v, %d, want %v, %d", fraction, complete, tt.wantFraction, tt.wantComplete)
+			}
+		})
+	}
+}
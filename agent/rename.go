@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// RenameAgent renames the installed agent, its SSM Cloud server and the
+// stored record. If any step fails, the steps that already ran are rolled
+// back.
+func RenameAgent(AgentName string, NewName string, prefs fyne.Preferences) error {
+	err := validateAgentName(NewName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := GetAgent(NewName); err == nil {
+		return errors.New("agent already exists with the same name")
+	}
+
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return err
+	}
+
+	if agent.State == StateProvisioning || agent.State == StateDeleting || agent.State == StateUpgrading {
+		return errors.New("agent can not be renamed while it is " + string(agent.State))
+	}
+
+	log.Printf("Renaming Agent %s to %s\r\n", AgentName, NewName)
+
+	previous := *agent
+	renamed := *agent
+	renamed.Name = NewName
+
+	steps := []transactionStep{
+		{
+			Name: "rename " + agent.AgentType + " agent",
+			Run:  func() error { return backend.Rename(prefs, previous, &renamed) },
+			Rollback: func() error {
+				restored := previous
+				return backend.Rename(prefs, renamed, &restored)
+			},
+		},
+	}
+
+	if agent.CloudServerID != "" {
+		steps = append(steps, transactionStep{
+			Name:     "rename ssm cloud server",
+			Run:      func() error { return RenameCloudServer(prefs, agent, NewName) },
+			Rollback: func() error { return RenameCloudServer(prefs, agent, AgentName) },
+		})
+	}
+
+	err = runTransaction(steps)
+	if err != nil {
+		var txErr *TransactionError
+		if errors.As(err, &txErr) && !txErr.RolledBackCleanly() {
+			agent.SetFailed(prefs, err)
+		}
+		return err
+	}
+
+	*agent = renamed
+	SaveAgents(prefs)
+	return nil
+}
+
+func validateAgentName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("agent name can not be empty")
+	}
+
+	if strings.ContainsAny(name, "/\\ ") {
+		return errors.New("agent name can not contain spaces or slashes")
+	}
+	return nil
+}
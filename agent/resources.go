@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	dockerContainer "github.com/docker/docker/api/types/container"
+)
+
+var cpusetPattern = regexp.MustCompile(`^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$`)
+
+// AgentResources are the optional docker resource limits of an agent. The
+// memory limit is kept in Agent.Memory. Zero values mean no limit.
+type AgentResources struct {
+	// CPUs is the number of cpus the container can use, like docker --cpus.
+	CPUs   float64 `json:"cpus,omitempty"`
+	CPUSet string  `json:"cpuset,omitempty"`
+	// MemoryReservation is the soft memory limit in bytes.
+	MemoryReservation int   `json:"memoryReservation,omitempty"`
+	PidsLimit         int64 `json:"pidsLimit,omitempty"`
+}
+
+func validateAgentResources(agent *Agent) error {
+	resources := agent.Resources
+
+	if resources.CPUs < 0 {
+		return errors.New("agent cpus can not be negative")
+	}
+
+	if resources.CPUSet != "" && !cpusetPattern.MatchString(resources.CPUSet) {
+		return errors.New("agent cpuset must be a list of cpus like 0-3 or 0,2")
+	}
+
+	if resources.MemoryReservation < 0 {
+		return errors.New("agent memory reservation can not be negative")
+	}
+
+	if resources.MemoryReservation > agent.Memory {
+		return errors.New("agent memory reservation can not be more than the memory limit")
+	}
+
+	if resources.PidsLimit < 0 {
+		return errors.New("agent pids limit can not be negative")
+	}
+
+	return nil
+}
+
+// getDockerResources returns the docker resources for the agent limits.
+func getDockerResources(agent *Agent) dockerContainer.Resources {
+	resources := dockerContainer.Resources{
+		Memory:            int64(agent.Memory),
+		MemoryReservation: int64(agent.Resources.MemoryReservation),
+		NanoCPUs:          int64(agent.Resources.CPUs * 1e9),
+		CpusetCpus:        agent.Resources.CPUSet,
+	}
+
+	if agent.Resources.PidsLimit > 0 {
+		pidsLimit := agent.Resources.PidsLimit
+		resources.PidsLimit = &pidsLimit
+	}
+
+	return resources
+}
+
+// resourceLimitRemoved reports whether a cpu, cpuset or memory reservation
+// limit was removed. Docker treats zero values as no change when updating a
+// container, so these limits can only be removed by recreating it.
+func resourceLimitRemoved(previous AgentResources, resources AgentResources) bool {
+	return (previous.CPUs > 0 && resources.CPUs == 0) ||
+		(previous.CPUSet != "" && resources.CPUSet == "") ||
+		(previous.MemoryReservation > 0 && resources.MemoryReservation == 0)
+}
+
+// getAgentResources reads the agent limits back from docker resources.
+func getAgentResources(resources dockerContainer.Resources) AgentResources {
+	agentResources := AgentResources{
+		CPUs:              float64(resources.NanoCPUs) / 1e9,
+		CPUSet:            resources.CpusetCpus,
+		MemoryReservation: int(resources.MemoryReservation),
+	}
+
+	if resources.PidsLimit != nil && *resources.PidsLimit > 0 {
+		agentResources.PidsLimit = *resources.PidsLimit
+	}
+
+	return agentResources
+}
+
+// ResourceLimits describes the agent memory and resource limits for status
+// output, or returns an empty string if there are none.
+func (a *Agent) ResourceLimits() string {
+	limits := []string{}
+
+	if a.Memory > 0 {
+		limits = append(limits, fmt.Sprintf("mem=%dG", a.Memory/1024/1024/1024))
+	}
+
+	if resources := a.Resources.String(); resources != "" {
+		limits = append(limits, resources)
+	}
+
+	return strings.Join(limits, ",")
+}
+
+func (r AgentResources) String() string {
+	limits := []string{}
+
+	if r.MemoryReservation > 0 {
+		limits = append(limits, fmt.Sprintf("memres=%dG", r.MemoryReservation/1024/1024/1024))
+	}
+
+	if r.CPUs > 0 {
+		limits = append(limits, "cpus="+strconv.FormatFloat(r.CPUs, 'f', -1, 64))
+	}
+
+	if r.CPUSet != "" {
+		limits = append(limits, "cpuset="+r.CPUSet)
+	}
+
+	if r.PidsLimit > 0 {
+		limits = append(limits, "pids="+strconv.FormatInt(r.PidsLimit, 10))
+	}
+
+	return strings.Join(limits, ",")
+}
@@ -0,0 +1,28 @@
+package agent
+
+import "testing"
+
+func TestResourceLimitRemoved(t *testing.T) {
+	tests := []struct {
+		name      string
+		previous  AgentResources
+		resources AgentResources
+		want      bool
+	}{
+		{name: "no limits", want: false},
+		{name: "limit added", resources: AgentResources{CPUs: 2}, want: false},
+		{name: "limit changed", previous: AgentResources{CPUs: 2}, resources: AgentResources{CPUs: 1.5}, want: false},
+		{name: "cpus removed", previous: AgentResources{CPUs: 2}, want: true},
+		{name: "cpuset removed", previous: AgentResources{CPUSet: "0-3"}, want: true},
+		{name: "memory reservation removed", previous: AgentResources{MemoryReservation: 1024}, want: true},
+		{name: "pids limit removed", previous: AgentResources{PidsLimit: 100}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resourceLimitRemoved(tt.previous, tt.resources); got != tt.want {
+				t.Errorf("resourceLimitRemoved() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	dockerContainer "github.com/docker/docker/api/types/container"
+)
+
+// DefaultRestartPolicy brings agents back after a host or docker restart
+// unless they were stopped on purpose.
+const DefaultRestartPolicy = "unless-stopped"
+
+// RestartPolicies are the docker restart policies an agent can use. The
+// on-failure policy can be given a retry count like on-failure:5.
+var RestartPolicies = []string{"no", "always", "unless-stopped", "on-failure"}
+
+// parseRestartPolicy converts a restart policy like on-failure:5 into a
+// docker restart policy.
+func parseRestartPolicy(restartPolicy string) (dockerContainer.RestartPolicy, error) {
+	name, retries, hasRetries := strings.Cut(restartPolicy, ":")
+
+	policy := dockerContainer.RestartPolicy{Name: name}
+
+	switch name {
+	case "no", "always", "unless-stopped":
+		if hasRetries {
+			return policy, errors.New("only the on-failure restart policy can have a retry count")
+		}
+	case "on-failure":
+		if !hasRetries {
+			break
+		}
+
+		count, err := strconv.Atoi(retries)
+		if err != nil || count < 0 {
+			return policy, errors.New("restart policy retry count must be a positive number")
+		}
+		policy.MaximumRetryCount = count
+	default:
+		return policy, errors.New("unknown restart policy " + restartPolicy + ", use one of " + strings.Join(RestartPolicies, ", "))
+	}
+
+	return policy, nil
+}
+
+// formatRestartPolicy converts a docker restart policy back to the agent
+// format.
+func formatRestartPolicy(policy dockerContainer.RestartPolicy) string {
+	if policy.Name == "" {
+		return "no"
+	}
+
+	if policy.Name == "on-failure" && policy.MaximumRetryCount > 0 {
+		return policy.Name + ":" + strconv.Itoa(policy.MaximumRetryCount)
+	}
+
+	return policy.Name
+}
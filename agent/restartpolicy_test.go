@@ -0,0 +1,42 @@
+package agent
+
+import "testing"
+
+func TestParseRestartPolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantName    string
+		wantRetries int
+		wantErr     bool
+	}{
+		{policy: "no", wantName: "no"},
+		{policy: "always", wantName: "always"},
+		{policy: "unless-stopped", wantName: "unless-stopped"},
+		{policy: "on-failure", wantName: "on-failure"},
+		{policy: "on-failure:5", wantName: "on-failure", wantRetries: 5},
+		{policy: "on-failure:-1", wantErr: true},
+		{policy: "on-failure:many", wantErr: true},
+		{policy: "always:3", wantErr: true},
+		{policy: "sometimes", wantErr: true},
+		{policy: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			policy, err := parseRestartPolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRestartPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if policy.Name != tt.wantName || policy.MaximumRetryCount != tt.wantRetries {
+				t.Errorf("parseRestartPolicy() = %s:%d, want %s:%d", policy.Name, policy.MaximumRetryCount, tt.wantName, tt.wantRetries)
+			}
+			if got := formatRestartPolicy(policy); got != tt.policy {
+				t.Errorf("formatRestartPolicy() = %s, want %s", got, tt.policy)
+			}
+		})
+	}
+}
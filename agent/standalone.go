@@ -0,0 +1,686 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+)
+
+// StandaloneBackend runs agents from the SSMAgent release as a systemd
+// service.
+type StandaloneBackend struct{}
+
+func (b *StandaloneBackend) Configure(agent *Agent) error {
+	if !isLocalEndpoint(agent.Endpoint) {
+		return errors.New("standalone agents can only run on the manager host")
+	}
+	agent.Endpoint = ""
+
+	dataDirectory := agent.DataDirectory
+	if dataDirectory == "" {
+		dataDirectory, _ = filepath.Abs("/SSM/data")
+	}
+
+	agent.DataDirectory = filepath.Join(dataDirectory, agent.Name)
+
+	var installBaseDirectory = ""
+
+	if runtime.GOOS == "windows" {
+		installBaseDirectory, _ = filepath.Abs("C:\\Program Files\\SSM\\Agents")
+	} else if runtime.GOOS == "linux" {
+		installBaseDirectory, _ = filepath.Abs("/opt/SSM/Agents")
+	}
+
+	agent.InstallDirectory = filepath.Join(installBaseDirectory, agent.Name)
+	agent.Memory = 0
+	agent.Resources = AgentResources{}
+	agent.RestartPolicy = ""
+	agent.Image = ""
+	agent.HealthCheck = AgentHealthCheck{}
+	return validateStandaloneNetwork(agent)
+}
+
+func (b *StandaloneBackend) Capabilities() BackendCapabilities {
+	return BackendCapabilities{}
+}
+
+func (b *StandaloneBackend) Migrate(agent *Agent) {
+}
+
+func (b *StandaloneBackend) Location(agent *Agent) string {
+	return agent.InstallDirectory
+}
+
+func (b *StandaloneBackend) Install(prefs fyne.Preferences, agent *Agent, opts InstallOptions) error {
+	steps := []transactionStep{
+		{
+			Name: "create agent directories",
+			Run: func() error {
+				utils.RemoveAll(agent.InstallDirectory)
+				utils.RemoveAll(agent.DataDirectory)
+
+				err := utils.CreateFolder(agent.InstallDirectory)
+				if err != nil {
+					return err
+				}
+				return utils.CreateFolder(agent.DataDirectory)
+			},
+			Rollback: func() error { return removeAgentDirectories(agent) },
+		},
+		{
+			Name: "download agent",
+			Run:  func() error { return DownloadAgent(prefs, agent) },
+		},
+	}
+
+	if runtime.GOOS == "linux" {
+		steps = append(steps, transactionStep{
+			Name:     "create service file",
+			Run:      func() error { return CreateLinuxAgentServiceFile(prefs, agent) },
+			Rollback: func() error { return utils.RemoveFile(GetLinuxServiceFilePath(agent)) },
+		})
+	}
+
+	return runTransaction(steps)
+}
+
+func (b *StandaloneBackend) Start(agent *Agent) error {
+	return runSystemctl(context.Background(), "start", GetLinuxServiceName(agent))
+}
+
+func (b *StandaloneBackend) Stop(agent *Agent, timeout int) error {
+	return stopLinuxService(agent, timeout)
+}
+
+func (b *StandaloneBackend) Status(agent *Agent) (AgentStatus, error) {
+	status := AgentStatus{}
+
+	properties, err := getLinuxServiceProperties(agent,
+		"ActiveState",
+		"SubState",
+		"MainPID",
+		"NRestarts",
+		"ActiveEnterTimestamp",
+	)
+	if err != nil {
+		return status, err
+	}
+
+	status.State = properties["ActiveState"]
+	status.SubState = properties["SubState"]
+	status.Running = status.State == "active"
+	status.PID, _ = strconv.Atoi(properties["MainPID"])
+	status.RestartCount, _ = strconv.Atoi(properties["NRestarts"])
+	status.StartedAt, _ = time.Parse("Mon 2006-01-02 15:04:05 MST", properties["ActiveEnterTimestamp"])
+	return status, nil
+}
+
+func (b *StandaloneBackend) Logs(agent *Agent, opts LogOptions, stdout io.Writer, stderr io.Writer) error {
+	if runtime.GOOS != "linux" {
+		return errors.New("standalone agent logs are only available on linux")
+	}
+
+	args := []string{"--unit=" + GetLinuxServiceName(agent), "--no-pager"}
+
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+
+	if opts.Tail != "" && opts.Tail != "all" {
+		args = append(args, "--lines="+opts.Tail)
+	}
+
+	if opts.Since != "" {
+		// journalctl does not understand durations, so turn them into a
+		// timestamp the same way docker does.
+		since := opts.Since
+		if d, err := time.ParseDuration(since); err == nil {
+			since = time.Now().Add(-d).Format("2006-01-02 15:04:05")
+		}
+		args = append(args, "--since="+since)
+	}
+
+	if !opts.Timestamps {
+		args = append(args, "--output=cat")
+	}
+
+	cmd := exec.Command("journalctl", args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// Upgrade downloads the latest agent release, restarting the service around
+// the download if it was running.
+func (b *StandaloneBackend) Upgrade(prefs fyne.Preferences, agent *Agent, opts UpgradeOptions) error {
+	if opts.Image != "" {
+		return errors.New("images can only be set on docker agents")
+	}
+
+	status, err := b.Status(agent)
+	if err != nil {
+		return err
+	}
+
+	if status.Running {
+		err = b.Stop(agent, DefaultStopTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = DownloadAgent(prefs, agent)
+	if err != nil {
+		return err
+	}
+
+	if status.Running {
+		return b.Start(agent)
+	}
+	return nil
+}
+
+func (b *StandaloneBackend) PlanUpdate(previous Agent, agent *Agent) ([]string, error) {
+	if agent.Memory != 0 {
+		return nil, errors.New("memory can only be set on docker agents")
+	}
+
+	if agent.Resources != (AgentResources{}) {
+		return nil, errors.New("resource limits can only be set on docker agents")
+	}
+
+	if agent.RestartPolicy != "" {
+		return nil, errors.New("restart policies can only be set on docker agents")
+	}
+
+	if agent.Image != "" {
+		return nil, errors.New("images can only be set on docker agents")
+	}
+
+	if agent.HealthCheck != (AgentHealthCheck{}) {
+		return nil, errors.New("health checks can only be set on docker agents")
+	}
+
+	err := validateStandaloneNetwork(agent)
+	if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Update rewrites the service file and reloads systemd, restarting the
+// service if it was running.
+func (b *StandaloneBackend) Update(prefs fyne.Preferences, previous Agent, agent *Agent) error {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	err := CreateLinuxAgentServiceFile(prefs, agent)
+	if err != nil {
+		return err
+	}
+
+	err = runSystemctl(context.Background(), "daemon-reload")
+	if err != nil {
+		return err
+	}
+
+	status, err := b.Status(agent)
+	if err != nil {
+		return err
+	}
+
+	if status.Running {
+		return runSystemctl(context.Background(), "restart", GetLinuxServiceName(agent))
+	}
+	return nil
+}
+
+// Rename stops the service, moves the agent directories and replaces the
+// service file, starting the service again if it was running.
+func (b *StandaloneBackend) Rename(prefs fyne.Preferences, previous Agent, agent *Agent) error {
+	agent.InstallDirectory = filepath.Join(filepath.Dir(previous.InstallDirectory), agent.Name)
+	agent.DataDirectory = filepath.Join(filepath.Dir(previous.DataDirectory), agent.Name)
+
+	wasRunning := false
+	if runtime.GOOS == "linux" {
+		status, err := b.Status(&previous)
+		if err != nil {
+			return err
+		}
+		wasRunning = status.Running
+	}
+
+	steps := []transactionStep{
+		{
+			Name:     "stop agent service",
+			Run:      func() error { return b.stopIf(wasRunning, &previous) },
+			Rollback: func() error { return b.startIf(wasRunning, &previous) },
+		},
+		{
+			Name:     "move install directory",
+			Run:      func() error { return utils.MovePath(previous.InstallDirectory, agent.InstallDirectory) },
+			Rollback: func() error { return utils.MovePath(agent.InstallDirectory, previous.InstallDirectory) },
+		},
+		{
+			Name:     "move data directory",
+			Run:      func() error { return utils.MovePath(previous.DataDirectory, agent.DataDirectory) },
+			Rollback: func() error { return utils.MovePath(agent.DataDirectory, previous.DataDirectory) },
+		},
+	}
+
+	if runtime.GOOS == "linux" {
+		steps = append(steps,
+			transactionStep{
+				Name: "replace service file",
+				Run: func() error {
+					err := CreateLinuxAgentServiceFile(prefs, agent)
+					if err != nil {
+						return err
+					}
+
+					err = utils.RemoveFile(GetLinuxServiceFilePath(&previous))
+					if err != nil {
+						return err
+					}
+					return runSystemctl(context.Background(), "daemon-reload")
+				},
+				Rollback: func() error {
+					err := CreateLinuxAgentServiceFile(prefs, &previous)
+					if err != nil {
+						return err
+					}
+
+					err = utils.RemoveFile(GetLinuxServiceFilePath(agent))
+					if err != nil {
+						return err
+					}
+					return runSystemctl(context.Background(), "daemon-reload")
+				},
+			},
+			transactionStep{
+				Name: "start agent service",
+				Run:  func() error { return b.startIf(wasRunning, agent) },
+			},
+		)
+	}
+
+	return runTransaction(steps)
+}
+
+func (b *StandaloneBackend) stopIf(running bool, agent *Agent) error {
+	if !running {
+		return nil
+	}
+	return b.Stop(agent, DefaultStopTimeout)
+}
+
+func (b *StandaloneBackend) startIf(running bool, agent *Agent) error {
+	if !running {
+		return nil
+	}
+	return b.Start(agent)
+}
+
+// Remove deletes the service and the agent directories, keeping the data
+// directory when keepData is set.
+func (b *StandaloneBackend) Remove(prefs fyne.Preferences, agent *Agent, keepData bool) error {
+	if runtime.GOOS == "linux" {
+		serviceFilePath := GetLinuxServiceFilePath(agent)
+		if utils.CheckFileExists(serviceFilePath) {
+			err := stopLinuxService(agent, DefaultStopTimeout)
+			if err != nil {
+				return err
+			}
+
+			// Disable the unit while systemd still has it, otherwise its
+			// enable links are left behind for a later agent with the
+			// same name.
+			err = runSystemctl(context.Background(), "disable", GetLinuxServiceName(agent))
+			if err != nil {
+				return err
+			}
+
+			err = utils.RemoveFile(serviceFilePath)
+			if err != nil {
+				return err
+			}
+
+			err = runSystemctl(context.Background(), "daemon-reload")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if keepData {
+		return utils.RemoveAll(agent.InstallDirectory)
+	}
+
+	return removeAgentDirectories(agent)
+}
+
+var (
+	serviceWorkingDirectoryRegex = regexp.MustCompile(`(?m)^WorkingDirectory=(.*)$`)
+	serviceExecStartRegex        = regexp.MustCompile(`(?m)^ExecStart=(.*)$`)
+	serviceArgRegex              = regexp.MustCompile(`-(p|apikey|datadir|multihome)=("[^"]*"|\S+)`)
+)
+
+// Discover reads the agent settings back from the SSMAgent service files.
+func (b *StandaloneBackend) Discover() ([]Agent, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	serviceFiles, err := filepath.Glob(filepath.Join("/etc/systemd/system", "SSMAgent@*.service"))
+	if err != nil {
+		return nil, err
+	}
+
+	agents := make([]Agent, 0, len(serviceFiles))
+	for _, serviceFile := range serviceFiles {
+		content, err := os.ReadFile(serviceFile)
+		if err != nil {
+			return nil, err
+		}
+
+		agent := Agent{
+			Name:      strings.TrimSuffix(strings.TrimPrefix(filepath.Base(serviceFile), "SSMAgent@"), ".service"),
+			AgentType: "standalone",
+			State:     StateStopped,
+		}
+
+		parseLinuxServiceFile(content, &agent)
+
+		status, err := b.Status(&agent)
+		if err == nil && status.Running {
+			agent.State = StateRunning
+		}
+
+		agents = append(agents, agent)
+	}
+
+	return agents, nil
+}
+
+// parseLinuxServiceFile reads the agent settings written by
+// CreateLinuxAgentServiceFile back from a service file.
+func parseLinuxServiceFile(content []byte, agent *Agent) {
+	if match := serviceWorkingDirectoryRegex.FindSubmatch(content); match != nil {
+		agent.InstallDirectory = strings.TrimSpace(string(match[1]))
+	}
+
+	if match := serviceExecStartRegex.FindSubmatch(content); match != nil {
+		for _, arg := range serviceArgRegex.FindAllStringSubmatch(string(match[1]), -1) {
+			value := strings.Trim(arg[2], `"`)
+			switch arg[1] {
+			case "p":
+				agent.PortOffset, _ = strconv.Atoi(value)
+			case "apikey":
+				agent.APIKey = value
+			case "datadir":
+				agent.DataDirectory = value
+			case "multihome":
+				agent.Network.BindIP = value
+			}
+		}
+	}
+}
+
+func (b *StandaloneBackend) Diagnose(prefs fyne.Preferences, agent *Agent) ([]DoctorIssue, error) {
+	issues := []DoctorIssue{}
+
+	if !utils.CheckFileExists(agent.InstallDirectory) {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMissing,
+			Message: "install directory " + agent.InstallDirectory + " does not exist",
+			Fix: func() error {
+				err := utils.CreateFolder(agent.InstallDirectory)
+				if err != nil {
+					return err
+				}
+				return DownloadAgent(prefs, agent)
+			},
+		})
+	}
+
+	if !utils.CheckFileExists(agent.DataDirectory) {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMissing,
+			Message: "data directory " + agent.DataDirectory + " does not exist",
+			Fix:     func() error { return utils.CreateFolder(agent.DataDirectory) },
+		})
+	}
+
+	if runtime.GOOS != "linux" {
+		return issues, nil
+	}
+
+	rewriteServiceFile := func() error {
+		err := CreateLinuxAgentServiceFile(prefs, agent)
+		if err != nil {
+			return err
+		}
+		return runSystemctl(context.Background(), "daemon-reload")
+	}
+
+	serviceFilePath := GetLinuxServiceFilePath(agent)
+	content, err := os.ReadFile(serviceFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMissing,
+			Message: "service file " + serviceFilePath + " does not exist",
+			Fix:     rewriteServiceFile,
+		})
+		return issues, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	installed := Agent{}
+	parseLinuxServiceFile(content, &installed)
+
+	if installed.PortOffset != agent.PortOffset {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: fmt.Sprintf("service port offset is %d, expected %d", installed.PortOffset, agent.PortOffset),
+			Fix:     rewriteServiceFile,
+		})
+	}
+
+	if installed.Network.BindIP != agent.Network.BindIP {
+		issues = append(issues, DoctorIssue{
+			Agent:   agent.Name,
+			Kind:    IssueMismatch,
+			Message: fmt.Sprintf("service bind ip is %q, expected %q", installed.Network.BindIP, agent.Network.BindIP),
+			Fix:     rewriteServiceFile,
+		})
+	}
+
+	status, err := b.Status(agent)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, diagnoseRunState(b, agent, status)...)
+
+	return issues, nil
+}
+
+func removeAgentDirectories(agent *Agent) error {
+	err := utils.RemoveAll(agent.InstallDirectory)
+	if err != nil {
+		return err
+	}
+
+	return utils.RemoveAll(agent.DataDirectory)
+}
+
+// getLinuxServiceProperties reads properties of the agent service unit using
+// systemctl show.
+func getLinuxServiceProperties(agent *Agent, properties ...string) (map[string]string, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("standalone agents can only be controlled on linux")
+	}
+
+	out, err := exec.Command("systemctl", "show", GetLinuxServiceName(agent), "--property="+strings.Join(properties, ",")).Output()
+	if err != nil {
+		return nil, fmt.Errorf("systemctl show failed: %w", err)
+	}
+
+	values := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if found {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func DownloadAgent(prefs fyne.Preferences, agent *Agent) error {
+	type gitRelease struct {
+		TagVersion string `json:"tag_name"`
+	}
+
+	resModel := gitRelease{}
+	err := utils.SendGetRequestURL(
+		prefs,
+		"https://api.github.com/repos/SatisfactoryServerManager/SSMAgent/releases/latest",
+		&resModel,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Latest Agent Version: %s\r\n", resModel.TagVersion)
+
+	downloadUrl := fmt.Sprintf("https://github.com/SatisfactoryServerManager/SSMAgent/releases/download/%s/", resModel.TagVersion)
+	osFile := ""
+	if runtime.GOOS == "linux" {
+		osFile = "SSMAgent-Linux-amd64.zip"
+	} else if runtime.GOOS == "windows" {
+		osFile = "SSMAgent-Windows-x64.zip"
+	} else {
+		return errors.New("unknown os")
+	}
+	downloadUrl += osFile
+
+	err = utils.DownloadFile(prefs, downloadUrl, filepath.Join(agent.InstallDirectory, "SSMAgent.zip"))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func CreateLinuxAgentServiceFile(prefs fyne.Preferences, agent *Agent) error {
+	if runtime.GOOS != "linux" {
+		return errors.New("can only create service file on linux")
+	}
+
+	// The server listens on every address unless it is given a multihome
+	// address.
+	multihome := ""
+	if agent.Network.BindIP != "" {
+		multihome = " -multihome=" + agent.Network.BindIP
+	}
+
+	serviceContent := fmt.Sprintf(`
+[Unit]
+Description=SSM Agent Daemon - %s
+After=network.target
+
+[Service]
+User=ssm
+Group=ssm
+
+Type=simple
+WorkingDirectory=%s
+ExecStart=%s/SSMAgent -name=%s -p=%d -url=%s -apikey=%s -datadir="%s"%s
+TimeoutStopSec=20
+KillMode=process
+Restart=on-failure
+
+[Install]
+WantedBy=multi-user.target
+	`,
+		agent.Name,
+		agent.InstallDirectory,
+		agent.InstallDirectory,
+		agent.Name,
+		agent.PortOffset,
+		utils.GetSSMURL(prefs),
+		agent.APIKey,
+		agent.DataDirectory,
+		multihome,
+	)
+
+	rawServiceFile := []byte(serviceContent)
+
+	err := utils.WriteFile(GetLinuxServiceFilePath(agent), rawServiceFile, 0777)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func GetLinuxServiceFilePath(agent *Agent) string {
+	return filepath.Join("/etc/systemd/system", GetLinuxServiceName(agent))
+}
+
+func GetLinuxServiceName(agent *Agent) string {
+	return "SSMAgent@" + agent.Name + ".service"
+}
+
+// stopLinuxService stops the agent service and kills it if it has not
+// stopped within the timeout.
+func stopLinuxService(agent *Agent, timeout int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	err := runSystemctl(ctx, "stop", GetLinuxServiceName(agent))
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	log.Printf("Agent %s did not stop within %d seconds, killing it\r\n", agent.Name, timeout)
+	return runSystemctl(context.Background(), "kill", "--signal=SIGKILL", GetLinuxServiceName(agent))
+}
+
+func runSystemctl(ctx context.Context, args ...string) error {
+	if runtime.GOOS != "linux" {
+		return errors.New("standalone agents can only be controlled on linux")
+	}
+
+	if utils.SkipForDryRun("systemctl %s", strings.Join(args, " ")) {
+		return nil
+	}
+
+	out, err := exec.CommandContext(ctx, "systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package agent
+
+import "testing"
+
+func TestParseLinuxServiceFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Agent
+	}{
+		{
+			name: "all arguments",
+			content: `[Unit]
+Description=SSM Agent
+
+[Service]
+WorkingDirectory=/opt/SSM/Agents/agent1
+ExecStart=/opt/SSM/Agents/agent1/SSMAgent -name=agent1 -p=2 -apikey=AGT-API-123 -datadir=/opt/SSM/data/agent1
+`,
+			want: Agent{InstallDirectory: "/opt/SSM/Agents/agent1", PortOffset: 2, APIKey: "AGT-API-123", DataDirectory: "/opt/SSM/data/agent1"},
+		},
+		{
+			name: "quoted data directory",
+			content: `WorkingDirectory=/opt/agent
+ExecStart=/opt/agent/SSMAgent -p=1 -datadir="/srv/ssm data"
+`,
+			want: Agent{InstallDirectory: "/opt/agent", PortOffset: 1, DataDirectory: "/srv/ssm data"},
+		},
+		{
+			name: "multihome address",
+			content: `ExecStart=/opt/agent/SSMAgent -p=3 -datadir="/srv/ssm" -multihome=192.168.1.20
+`,
+			want: Agent{PortOffset: 3, DataDirectory: "/srv/ssm", Network: AgentNetwork{BindIP: "192.168.1.20"}},
+		},
+		{
+			name:    "no exec start",
+			content: "WorkingDirectory=/opt/agent\n",
+			want:    Agent{InstallDirectory: "/opt/agent"},
+		},
+		{
+			name:    "empty file",
+			content: "",
+			want:    Agent{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := Agent{}
+			parseLinuxServiceFile([]byte(tt.content), &agent)
+
+			if agent.InstallDirectory != tt.want.InstallDirectory ||
+				agent.PortOffset != tt.want.PortOffset ||
+				agent.APIKey != tt.want.APIKey ||
+				agent.DataDirectory != tt.want.DataDirectory ||
+				agent.Network != tt.want.Network {
+				t.Errorf("parseLinuxServiceFile() = %+v, want %+v", agent, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+type AgentState string
+
+const (
+	StateProvisioning AgentState = "provisioning"
+	StateInstalled    AgentState = "installed"
+	StateRunning      AgentState = "running"
+	StateStopped      AgentState = "stopped"
+	StateUpgrading    AgentState = "upgrading"
+	StateFailed       AgentState = "failed"
+	StateDeleting     AgentState = "deleting"
+)
+
+// agentStateTransitions lists the states an agent is allowed to move to from
+// each state. Any state can move to failed.
+var agentStateTransitions = map[AgentState][]AgentState{
+	StateProvisioning: {StateInstalled, StateDeleting},
+	StateInstalled:    {StateRunning, StateStopped, StateUpgrading, StateDeleting},
+	StateRunning:      {StateRunning, StateStopped, StateUpgrading, StateDeleting},
+	StateStopped:      {StateRunning, StateStopped, StateUpgrading, StateDeleting},
+	StateUpgrading:    {StateInstalled, StateRunning, StateStopped},
+	StateFailed:       {StateProvisioning, StateInstalled, StateRunning, StateStopped, StateUpgrading, StateDeleting},
+	StateDeleting:     {StateDeleting},
+}
+
+func (s AgentState) CanTransitionTo(newState AgentState) bool {
+	if newState == StateFailed {
+		return true
+	}
+
+	for _, allowed := range agentStateTransitions[s] {
+		if allowed == newState {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransition returns an error if the agent can not move to newState.
+// Operations check it before changing anything on the host.
+func (a *Agent) checkTransition(newState AgentState) error {
+	if !a.State.CanTransitionTo(newState) {
+		return fmt.Errorf("agent %s can not move from %s to %s", a.Name, a.State, newState)
+	}
+	return nil
+}
+
+// SetState moves the agent to a new lifecycle state and saves the inventory so
+// the change is visible even if the operation is interrupted.
+func (a *Agent) SetState(prefs fyne.Preferences, newState AgentState) error {
+	err := a.checkTransition(newState)
+	if err != nil {
+		return err
+	}
+
+	a.State = newState
+	a.StateChangedAt = time.Now().UTC()
+
+	if newState != StateFailed {
+		a.LastError = ""
+	}
+
+	SaveAgents(prefs)
+	return nil
+}
+
+// SetFailed moves the agent to the failed state and records the error that
+// caused it.
+func (a *Agent) SetFailed(prefs fyne.Preferences, err error) {
+	a.State = StateFailed
+	a.StateChangedAt = time.Now().UTC()
+
+	if err != nil {
+		a.LastError = err.Error()
+	}
+
+	SaveAgents(prefs)
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from AgentState
+		to   AgentState
+		want bool
+	}{
+		{StateProvisioning, StateInstalled, true},
+		{StateProvisioning, StateRunning, false},
+		{StateInstalled, StateRunning, true},
+		{StateRunning, StateStopped, true},
+		{StateRunning, StateProvisioning, false},
+		{StateStopped, StateUpgrading, true},
+		{StateUpgrading, StateDeleting, false},
+		{StateDeleting, StateRunning, false},
+		{StateDeleting, StateFailed, true},
+		{StateFailed, StateRunning, true},
+		{StateRunning, StateFailed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("CanTransitionTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetState(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      AgentState
+		to        AgentState
+		wantErr   bool
+		wantState AgentState
+	}{
+		{name: "allowed transition", from: StateStopped, to: StateRunning, wantState: StateRunning},
+		{name: "refused transition", from: StateDeleting, to: StateRunning, wantErr: true, wantState: StateDeleting},
+		{name: "recover from failed", from: StateFailed, to: StateStopped, wantState: StateStopped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := test.NewApp().Preferences()
+			agent := &Agent{Name: "agent1", State: tt.from, LastError: "earlier"}
+
+			err := agent.SetState(prefs, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if agent.State != tt.wantState {
+				t.Errorf("state = %s, want %s", agent.State, tt.wantState)
+			}
+			if !tt.wantErr && agent.LastError != "" {
+				t.Errorf("last error = %q, should be cleared", agent.LastError)
+			}
+		})
+	}
+}
+
+func TestSetFailed(t *testing.T) {
+	prefs := test.NewApp().Preferences()
+	agent := &Agent{Name: "agent1", State: StateRunning}
+
+	agent.SetFailed(prefs, errors.New("container exited"))
+	if agent.State != StateFailed || agent.LastError != "container exited" {
+		t.Errorf("agent = %s %q, want failed with the error", agent.State, agent.LastError)
+	}
+}
@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	dockerMount "github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+)
+
+// dockerAgentMountTargets are the paths inside the ssmagent image that hold
+// data which must survive the container being recreated.
+var dockerAgentMountTargets = []struct {
+	Name   string
+	Target string
+}{
+	{Name: "data", Target: "/home/ssm/.SSM"},
+	{Name: "saves", Target: "/home/ssm/.config/Epic/FactoryGame"},
+	{Name: "gamefiles", Target: "/opt/SFServer"},
+	{Name: "logs", Target: "/home/ssm/.SSM/logs"},
+}
+
+// AgentMount is a docker volume or host directory mounted into an agent
+// container. The source is stored when the agent is created so renaming the
+// agent keeps using the same data.
+type AgentMount struct {
+	Name string `json:"name"`
+	// Type is either volume or bind.
+	Type   string `json:"type"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// getDockerAgentMounts returns bind mounts below the data directory when one
+// is set, otherwise a named volume for every mount.
+func getDockerAgentMounts(agent *Agent) []AgentMount {
+	mounts := make([]AgentMount, 0, len(dockerAgentMountTargets))
+
+	for _, mountTarget := range dockerAgentMountTargets {
+		mount := AgentMount{
+			Name:   mountTarget.Name,
+			Type:   string(dockerMount.TypeVolume),
+			Source: "ssmagent-" + agent.Name + "-" + mountTarget.Name,
+			Target: mountTarget.Target,
+		}
+
+		if agent.DataDirectory != "" {
+			mount.Type = string(dockerMount.TypeBind)
+			mount.Source = filepath.Join(agent.DataDirectory, mountTarget.Name)
+		}
+
+		mounts = append(mounts, mount)
+	}
+
+	return mounts
+}
+
+func getDockerMounts(agent *Agent) []dockerMount.Mount {
+	mounts := make([]dockerMount.Mount, 0, len(agent.Mounts))
+	for _, mount := range agent.Mounts {
+		mounts = append(mounts, dockerMount.Mount{
+			Type:   dockerMount.Type(mount.Type),
+			Source: mount.Source,
+			Target: mount.Target,
+		})
+	}
+	return mounts
+}
+
+// getAgentMounts reads the agent mounts back from a container, naming them
+// by their target path.
+func getAgentMounts(mounts []dockerMount.Mount) []AgentMount {
+	agentMounts := []AgentMount{}
+
+	for _, mount := range mounts {
+		for _, mountTarget := range dockerAgentMountTargets {
+			if mount.Target != mountTarget.Target {
+				continue
+			}
+
+			agentMounts = append(agentMounts, AgentMount{
+				Name:   mountTarget.Name,
+				Type:   string(mount.Type),
+				Source: mount.Source,
+				Target: mount.Target,
+			})
+		}
+	}
+
+	return agentMounts
+}
+
+// createBindMountDirectories creates the host directories of bind mounts,
+// docker does not create them for mounts.
+func createBindMountDirectories(agent *Agent) error {
+	for _, mount := range agent.Mounts {
+		if mount.Type != string(dockerMount.TypeBind) {
+			continue
+		}
+
+		err := utils.CreateFolder(mount.Source)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveDockerAgentData removes the named volumes of the agent and its data
+// directory.
+func RemoveDockerAgentData(agent *Agent) error {
+	ctx := context.Background()
+	cli, err := newDockerClient(agent)
+	if err != nil {
+		return err
+	}
+
+	for _, mount := range agent.Mounts {
+		if mount.Type != string(dockerMount.TypeVolume) {
+			continue
+		}
+
+		if utils.SkipForDryRun("remove docker volume %s", mount.Source) {
+			continue
+		}
+
+		log.Printf("Removing docker volume %s\r\n", mount.Source)
+		err := cli.VolumeRemove(ctx, mount.Source, false)
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+	}
+
+	if agent.DataDirectory == "" {
+		return nil
+	}
+
+	return utils.RemoveAll(agent.DataDirectory)
+}
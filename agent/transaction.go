@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// transactionStep is a single step of a multi step operation. Rollback undoes
+// the work done by Run and is nil when there is nothing to undo.
+type transactionStep struct {
+	Name     string
+	Run      func() error
+	Rollback func() error
+}
+
+type TransactionError struct {
+	FailedStep     string
+	Err            error
+	RolledBack     []string
+	RollbackErrors []error
+}
+
+func (e *TransactionError) Error() string {
+	msg := fmt.Sprintf("%s failed: %s", e.FailedStep, e.Err.Error())
+
+	if len(e.RolledBack) > 0 {
+		msg += fmt.Sprintf(", rolled back: %s", strings.Join(e.RolledBack, ", "))
+	}
+
+	if len(e.RollbackErrors) > 0 {
+		rollbackErrors := make([]string, 0, len(e.RollbackErrors))
+		for _, err := range e.RollbackErrors {
+			rollbackErrors = append(rollbackErrors, err.Error())
+		}
+		msg += fmt.Sprintf(", rollback errors: %s", strings.Join(rollbackErrors, "; "))
+	}
+
+	return msg
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// RolledBackCleanly reports whether every completed step, including the steps
+// of nested transactions, was rolled back without errors.
+func (e *TransactionError) RolledBackCleanly() bool {
+	if len(e.RollbackErrors) > 0 {
+		return false
+	}
+
+	var nested *TransactionError
+	if errors.As(e.Err, &nested) {
+		return nested.RolledBackCleanly()
+	}
+	return true
+}
+
+// runTransaction runs the steps in order. If a step fails, the steps that
+// already ran are rolled back in reverse order.
+func runTransaction(steps []transactionStep) error {
+	for idx, step := range steps {
+		log.Printf("Running step: %s\r\n", step.Name)
+
+		err := step.Run()
+		if err == nil {
+			continue
+		}
+
+		txErr := &TransactionError{
+			FailedStep: step.Name,
+			Err:        err,
+		}
+
+		for i := idx - 1; i >= 0; i-- {
+			completed := steps[i]
+			if completed.Rollback == nil {
+				continue
+			}
+
+			log.Printf("Rolling back step: %s\r\n", completed.Name)
+
+			rollbackErr := completed.Rollback()
+			if rollbackErr != nil {
+				txErr.RollbackErrors = append(txErr.RollbackErrors, fmt.Errorf("%s: %w", completed.Name, rollbackErr))
+				continue
+			}
+			txErr.RolledBack = append(txErr.RolledBack, completed.Name)
+		}
+
+		return txErr
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRunTransaction(t *testing.T) {
+	errFailed := errors.New("failed")
+	errRollback := errors.New("rollback failed")
+
+	tests := []struct {
+		name string
+		// fail is the index of the step that fails, -1 for none.
+		fail int
+		// rollbackFail is the index of the step whose rollback fails, -1 for
+		// none.
+		rollbackFail   int
+		wantRan        []string
+		wantRolledBack []string
+		wantClean      bool
+	}{
+		{name: "all steps succeed", fail: -1, rollbackFail: -1, wantRan: []string{"a", "b", "c"}},
+		{name: "first step fails", fail: 0, rollbackFail: -1, wantRan: []string{"a"}, wantClean: true},
+		{name: "last step rolls back in reverse", fail: 2, rollbackFail: -1, wantRan: []string{"a", "b", "c", "rollback b", "rollback a"}, wantRolledBack: []string{"b", "a"}, wantClean: true},
+		{name: "rollback error continues", fail: 2, rollbackFail: 1, wantRan: []string{"a", "b", "c", "rollback b", "rollback a"}, wantRolledBack: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := []string{}
+			steps := []transactionStep{}
+			for idx, name := range []string{"a", "b", "c"} {
+				idx, name := idx, name
+				steps = append(steps, transactionStep{
+					Name: name,
+					Run: func() error {
+						ran = append(ran, name)
+						if idx == tt.fail {
+							return errFailed
+						}
+						return nil
+					},
+					Rollback: func() error {
+						ran = append(ran, "rollback "+name)
+						if idx == tt.rollbackFail {
+							return errRollback
+						}
+						return nil
+					},
+				})
+			}
+
+			err := runTransaction(steps)
+			if !reflect.DeepEqual(ran, tt.wantRan) {
+				t.Errorf("ran = %v, want %v", ran, tt.wantRan)
+			}
+
+			if tt.fail < 0 {
+				if err != nil {
+					t.Fatalf("runTransaction() error = %v", err)
+				}
+				return
+			}
+
+			var txErr *TransactionError
+			if !errors.As(err, &txErr) {
+				t.Fatalf("runTransaction() error = %v, want a TransactionError", err)
+			}
+			if !errors.Is(err, errFailed) {
+				t.Errorf("error should wrap the failed step error")
+			}
+			if !reflect.DeepEqual(txErr.RolledBack, tt.wantRolledBack) {
+				t.Errorf("rolled back = %v, want %v", txErr.RolledBack, tt.wantRolledBack)
+			}
+			if txErr.RolledBackCleanly() != tt.wantClean {
+				t.Errorf("RolledBackCleanly() = %v, want %v", txErr.RolledBackCleanly(), tt.wantClean)
+			}
+		})
+	}
+}
+
+func TestTransactionErrorNested(t *testing.T) {
+	nested := &TransactionError{FailedStep: "inner", Err: errors.New("no"), RollbackErrors: []error{errors.New("stuck")}}
+	outer := &TransactionError{FailedStep: "outer", Err: nested}
+
+	if outer.RolledBackCleanly() {
+		t.Error("rollback errors of nested transactions should be reported")
+	}
+}
@@ -0,0 +1,211 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"fyne.io/fyne/v2"
+)
+
+// UpdateAgentOptions holds the agent settings to change. Nil fields are left
+// as they are.
+type UpdateAgentOptions struct {
+	PortOffset *int
+	// Memory is the docker memory limit in GB.
+	Memory *int
+	CPUs   *float64
+	CPUSet *string
+	// MemoryReservation is the docker soft memory limit in GB.
+	MemoryReservation *int
+	PidsLimit         *int64
+	RestartPolicy     *string
+	Image             *string
+	NetworkMode       *string
+	NetworkName       *string
+	BindIP            *string
+	HealthCheck       *AgentHealthCheck
+}
+
+type AgentUpdatePlan struct {
+	Changes []string
+	// Warnings are the reasons applying the update is unsafe and needs
+	// confirming.
+	Warnings []string
+}
+
+func (p *AgentUpdatePlan) IsUnsafe() bool {
+	return len(p.Warnings) > 0
+}
+
+// PlanAgentUpdate checks the changes to an agent without applying them.
+func PlanAgentUpdate(AgentName string, opts UpdateAgentOptions) (*AgentUpdatePlan, error) {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := applyUpdateOptions(*agent, opts)
+	return planAgentUpdate(backend, *agent, &updated)
+}
+
+func UpdateAgent(AgentName string, opts UpdateAgentOptions, prefs fyne.Preferences) error {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return err
+	}
+
+	previous := *agent
+	updated := applyUpdateOptions(previous, opts)
+
+	plan, err := planAgentUpdate(backend, previous, &updated)
+	if err != nil {
+		return err
+	}
+
+	if len(plan.Changes) == 0 {
+		log.Printf("Agent %s has no changes to apply\r\n", AgentName)
+		return nil
+	}
+
+	log.Printf("Updating Agent %s\r\n", AgentName)
+
+	// The record keeps the previous settings until the backend has applied
+	// the update, so a failed update does not leave settings on it that were
+	// never installed.
+	err = backend.Update(prefs, previous, &updated)
+	if err != nil {
+		var txErr *TransactionError
+		if errors.As(err, &txErr) && txErr.RolledBackCleanly() {
+			log.Printf("Update of Agent %s was rolled back\r\n", AgentName)
+			return err
+		}
+
+		agent.SetFailed(prefs, err)
+		return err
+	}
+
+	*agent = updated
+	SaveAgents(prefs)
+	return nil
+}
+
+func applyUpdateOptions(agent Agent, opts UpdateAgentOptions) Agent {
+	if opts.PortOffset != nil {
+		agent.PortOffset = *opts.PortOffset
+	}
+
+	if opts.Memory != nil {
+		agent.Memory = *opts.Memory * 1024 * 1024 * 1024
+	}
+
+	if opts.CPUs != nil {
+		agent.Resources.CPUs = *opts.CPUs
+	}
+
+	if opts.CPUSet != nil {
+		agent.Resources.CPUSet = *opts.CPUSet
+	}
+
+	if opts.MemoryReservation != nil {
+		agent.Resources.MemoryReservation = *opts.MemoryReservation * 1024 * 1024 * 1024
+	}
+
+	if opts.PidsLimit != nil {
+		agent.Resources.PidsLimit = *opts.PidsLimit
+	}
+
+	if opts.RestartPolicy != nil {
+		agent.RestartPolicy = *opts.RestartPolicy
+	}
+
+	if opts.Image != nil {
+		agent.Image = *opts.Image
+	}
+
+	if opts.NetworkMode != nil {
+		agent.Network.Mode = *opts.NetworkMode
+	}
+
+	if opts.NetworkName != nil {
+		agent.Network.Name = *opts.NetworkName
+	}
+
+	if opts.BindIP != nil {
+		agent.Network.BindIP = *opts.BindIP
+	}
+
+	if opts.HealthCheck != nil {
+		agent.HealthCheck = *opts.HealthCheck
+	}
+	return agent
+}
+
+func planAgentUpdate(backend AgentBackend, previous Agent, updated *Agent) (*AgentUpdatePlan, error) {
+	if updated.PortOffset < 0 {
+		return nil, errors.New("agent port offset can not be negative")
+	}
+
+	plan := &AgentUpdatePlan{}
+
+	if previous.PortOffset != updated.PortOffset {
+		plan.Changes = append(plan.Changes, fmt.Sprintf("port offset %d -> %d", previous.PortOffset, updated.PortOffset))
+	}
+
+	if previous.Memory != updated.Memory {
+		plan.Changes = append(plan.Changes, fmt.Sprintf("memory %dGB -> %dGB", previous.Memory/1024/1024/1024, updated.Memory/1024/1024/1024))
+	}
+
+	if previous.Resources != updated.Resources {
+		plan.Changes = append(plan.Changes, fmt.Sprintf("resource limits %s -> %s", valueOrNone(previous.Resources.String()), valueOrNone(updated.Resources.String())))
+	}
+
+	if previous.RestartPolicy != updated.RestartPolicy {
+		plan.Changes = append(plan.Changes, fmt.Sprintf("restart policy %s -> %s", valueOrNone(previous.RestartPolicy), valueOrNone(updated.RestartPolicy)))
+	}
+
+	if previous.Image != updated.Image {
+		plan.Changes = append(plan.Changes, fmt.Sprintf("image %s -> %s", valueOrNone(previous.Image), valueOrNone(updated.Image)))
+	}
+
+	warnings, err := backend.PlanUpdate(previous, updated)
+	if err != nil {
+		return nil, err
+	}
+
+	// PlanUpdate normalises the network and health check settings, so
+	// compare them once it has run.
+	if previous.Network != updated.Network {
+		plan.Changes = append(plan.Changes, fmt.Sprintf("network %s -> %s", previous.Network, updated.Network))
+	}
+
+	if previous.HealthCheck != updated.HealthCheck {
+		plan.Changes = append(plan.Changes, fmt.Sprintf("health check %s -> %s", valueOrNone(previous.HealthCheck.String()), valueOrNone(updated.HealthCheck.String())))
+	}
+
+	if len(plan.Changes) == 0 {
+		return plan, nil
+	}
+
+	plan.Warnings = warnings
+
+	// Resource limits and the restart policy are applied to the running
+	// container, other changes need a restart.
+	restart := previous.PortOffset != updated.PortOffset ||
+		previous.Memory != updated.Memory ||
+		previous.Image != updated.Image ||
+		previous.Network != updated.Network ||
+		previous.HealthCheck != updated.HealthCheck
+	if previous.State == StateRunning && restart {
+		plan.Warnings = append(plan.Warnings, "the agent is running and will be restarted")
+	}
+
+	return plan, nil
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
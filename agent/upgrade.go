@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// DefaultUpgradeHealthTimeout is how long an upgraded agent is given to
+// become healthy before the upgrade is rolled back.
+const DefaultUpgradeHealthTimeout = 2 * time.Minute
+
+type UpgradeOptions struct {
+	// Image is the docker image to upgrade to, the current agent image when
+	// empty.
+	Image string
+	// HealthTimeout is how long the upgraded agent has to become healthy.
+	HealthTimeout time.Duration
+	// OnPullProgress is called for every event in the image pull stream.
+	OnPullProgress func(PullProgress)
+}
+
+// UpgradeAgent moves an agent to a new release. If the upgrade fails and is
+// rolled back cleanly the agent is returned to its previous state, otherwise
+// it is marked as failed.
+func UpgradeAgent(AgentName string, opts UpgradeOptions, prefs fyne.Preferences) error {
+	agent, backend, err := getAgentWithBackend(AgentName)
+	if err != nil {
+		return err
+	}
+
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = DefaultUpgradeHealthTimeout
+	}
+
+	// Give the health check long enough to report on the new container.
+	if settleTime := agent.HealthCheck.settleTime(); opts.HealthTimeout < settleTime {
+		opts.HealthTimeout = settleTime
+	}
+
+	previousState := agent.State
+
+	err = agent.SetState(prefs, StateUpgrading)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Upgrading Agent %s\r\n", agent.Name)
+
+	err = backend.Upgrade(prefs, agent, opts)
+	if err != nil {
+		var txErr *TransactionError
+		if errors.As(err, &txErr) && txErr.RolledBackCleanly() && previousState != StateFailed {
+			log.Printf("Upgrade of Agent %s was rolled back\r\n", agent.Name)
+			agent.SetState(prefs, previousState)
+			return err
+		}
+
+		agent.SetFailed(prefs, err)
+		return err
+	}
+
+	if previousState == StateFailed {
+		previousState = StateInstalled
+	}
+	return agent.SetState(prefs, previousState)
+}
+
+// GetContainerAgentNames returns the names of all agents that run in
+// containers, which are the agents that can be upgraded.
+func GetContainerAgentNames() []string {
+	names := []string{}
+	for idx := range AllAgents.Agents {
+		agent := &AllAgents.Agents[idx]
+		if agent.Capabilities().Container {
+			names = append(names, agent.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+package agents
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"github.com/spf13/cobra"
+)
+
+var adoptCmdYesFlag bool
+
+func init() {
+	Cmd.AddCommand(adoptCmd)
+}
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [name...]",
+	Short: "Imports unmanaged agents into the inventory",
+	Long:  `Scans docker and systemd for ssm agents that are not in the agent inventory and imports them. Without names every discovered agent is offered`,
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		discovered, err := agent.DiscoverAgents()
+		if err != nil {
+			// The agents that could be scanned are still listed.
+			log.Printf("Warning: not every agent could be discovered, with error %s\r\n", err.Error())
+		}
+
+		for _, a := range discovered {
+			if len(args) > 0 && !containsString(args, a.Name) {
+				continue
+			}
+
+			if !adoptCmdYesFlag && !utils.AskForConfirmation(fmt.Sprintf("Import %s agent %s?", a.AgentType, a.Name)) {
+				continue
+			}
+
+			err := agent.AdoptAgent(a, gui.MainApp.Preferences())
+			if err != nil {
+				log.Printf("Error adopting agent %s, with error %s\r\n", a.Name, err.Error())
+			}
+		}
+	},
+}
+
+func init() {
+	adoptCmd.Flags().BoolVarP(&adoptCmdYesFlag, "yes", "y", false, "Import without asking")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,18 @@ var createCmdTypeFlag string
 var createCmdPortOffsetFlag int
 var createCmdMemoryFlag int
 var createCmdDataDirFlag string
+var createCmdCPUsFlag float64
+var createCmdCPUSetFlag string
+var createCmdMemoryReservationFlag int
+var createCmdPidsLimitFlag int64
+var createCmdRestartFlag string
+var createCmdNoPullFlag bool
+var createCmdImageFlag string
+var createCmdNetworkModeFlag string
+var createCmdNetworkFlag string
+var createCmdBindIPFlag string
+var createCmdHealthCheck agent.AgentHealthCheck
+var createCmdEndpointFlag string
 
 func init() {
 	Cmd.AddCommand(createCmd)
@@ -24,14 +36,38 @@ var createCmd = &cobra.Command{
 	Long:  `Creates a new ssm agent and adds it to your SSM account`,
 	Run: func(cmd *cobra.Command, args []string) {
 		agent.LoadAgents(gui.MainApp.Preferences())
+
+		progressBar := newPullProgressBar()
 		_, err := agent.CreateNewAgent(
 			createCmdNameFlag,
 			createCmdTypeFlag,
 			createCmdPortOffsetFlag,
 			createCmdMemoryFlag,
 			createCmdDataDirFlag,
+			agent.CreateAgentOptions{
+				Resources: agent.AgentResources{
+					CPUs:              createCmdCPUsFlag,
+					CPUSet:            createCmdCPUSetFlag,
+					MemoryReservation: createCmdMemoryReservationFlag,
+					PidsLimit:         createCmdPidsLimitFlag,
+				},
+				RestartPolicy: createCmdRestartFlag,
+				Image:         createCmdImageFlag,
+				Network: agent.AgentNetwork{
+					Mode:   createCmdNetworkModeFlag,
+					Name:   createCmdNetworkFlag,
+					BindIP: createCmdBindIPFlag,
+				},
+				HealthCheck: createCmdHealthCheck,
+				Endpoint:    createCmdEndpointFlag,
+				Install: agent.InstallOptions{
+					NoPull:         createCmdNoPullFlag,
+					OnPullProgress: progressBar.Update,
+				},
+			},
 			gui.MainApp.Preferences(),
 		)
+		progressBar.Finish()
 
 		if err != nil {
 			log.Printf("Error creating agent, with error %s\r\n", err.Error())
@@ -45,7 +81,19 @@ func init() {
 	createCmd.Flags().StringVarP(&createCmdTypeFlag, "type", "t", "docker", "The SSM Agent Type [docker|standalone]")
 	createCmd.Flags().IntVarP(&createCmdPortOffsetFlag, "portoffset", "p", 0, "The SSM Agent Port Offset")
 	createCmd.Flags().IntVarP(&createCmdMemoryFlag, "memory", "m", 0, "The SSM Agent Docker Memory Limit")
-	createCmd.Flags().StringVarP(&createCmdDataDirFlag, "datadir", "d", "", "The SSM Agent Standalone Data Directory")
+	createCmd.Flags().Float64Var(&createCmdCPUsFlag, "cpus", 0, "The SSM Agent Docker CPU Limit")
+	createCmd.Flags().StringVar(&createCmdCPUSetFlag, "cpuset", "", "The CPUs the SSM Agent Docker container can use (0-3, 0,1)")
+	createCmd.Flags().IntVar(&createCmdMemoryReservationFlag, "memory-reservation", 0, "The SSM Agent Docker Memory Reservation in GB")
+	createCmd.Flags().Int64Var(&createCmdPidsLimitFlag, "pids-limit", 0, "The SSM Agent Docker Pids Limit")
+	createCmd.Flags().StringVar(&createCmdRestartFlag, "restart", agent.DefaultRestartPolicy, "The SSM Agent Docker Restart Policy [no|always|unless-stopped|on-failure[:N]]")
+	createCmd.Flags().StringVar(&createCmdImageFlag, "image", "", "The SSM Agent Docker Image with a tag or digest, defaults to the manager docker image")
+	createCmd.Flags().BoolVar(&createCmdNoPullFlag, "no-pull", false, "Use the docker image already on the host instead of pulling it")
+	createCmd.Flags().StringVar(&createCmdNetworkModeFlag, "network-mode", "", "The SSM Agent Docker Network Mode [bridge|host|user|external], defaults to bridge")
+	createCmd.Flags().StringVar(&createCmdNetworkFlag, "network", "", "The SSM Agent Docker Network Name for the user and external network modes")
+	createCmd.Flags().StringVar(&createCmdBindIPFlag, "bind-ip", "", "The host IP address the SSM Agent ports are published on, defaults to all addresses")
+	addHealthCheckFlags(createCmd, &createCmdHealthCheck)
+	createCmd.Flags().StringVarP(&createCmdEndpointFlag, "endpoint", "e", agent.LocalEndpointName, "The docker endpoint to place the SSM Agent on, see config endpoint list")
+	createCmd.Flags().StringVarP(&createCmdDataDirFlag, "datadir", "d", "", "The SSM Agent Data Directory, docker agents use named volumes when not set")
 
 	createCmd.MarkFlagRequired("name")
 	createCmd.MarkFlagRequired("type")
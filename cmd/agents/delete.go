@@ -9,6 +9,9 @@ import (
 )
 
 var deleteCmdNameFlag string
+var deleteCmdKeepCloudFlag bool
+var deleteCmdCloudOnlyFlag bool
+var deleteCmdKeepDataFlag bool
 
 func init() {
 	Cmd.AddCommand(deleteCmd)
@@ -22,6 +25,11 @@ var deleteCmd = &cobra.Command{
 		agent.LoadAgents(gui.MainApp.Preferences())
 		err := agent.DeleteAgent(
 			deleteCmdNameFlag,
+			agent.DeleteAgentOptions{
+				KeepCloud: deleteCmdKeepCloudFlag,
+				CloudOnly: deleteCmdCloudOnlyFlag,
+				KeepData:  deleteCmdKeepDataFlag,
+			},
 			gui.MainApp.Preferences(),
 		)
 
@@ -34,6 +42,11 @@ var deleteCmd = &cobra.Command{
 
 func init() {
 	deleteCmd.Flags().StringVarP(&deleteCmdNameFlag, "name", "n", "", "The SSM Agent Name")
+	deleteCmd.Flags().BoolVar(&deleteCmdKeepCloudFlag, "keep-cloud", false, "Keep the server registered in SSM Cloud")
+	deleteCmd.Flags().BoolVar(&deleteCmdCloudOnlyFlag, "cloud-only", false, "Only remove the server from SSM Cloud and keep the local agent")
+
+	deleteCmd.Flags().BoolVar(&deleteCmdKeepDataFlag, "keep-data", false, "Keep the agent volumes and data directory")
 
 	deleteCmd.MarkFlagRequired("name")
+	deleteCmd.MarkFlagsMutuallyExclusive("keep-cloud", "cloud-only")
 }
@@ -0,0 +1,49 @@
+package agents
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	Cmd.AddCommand(discoverCmd)
+}
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Lists agents on this host that are not managed",
+	Long:  `Scans docker and systemd for ssm agents that are not in the agent inventory`,
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		discovered, err := agent.DiscoverAgents()
+		if err != nil {
+			// The agents that could be scanned are still listed.
+			log.Printf("Warning: not every agent could be discovered, with error %s\r\n", err.Error())
+		}
+
+		if len(discovered) == 0 {
+			fmt.Println("No unmanaged agents found")
+			return
+		}
+
+		printDiscoveredAgents(discovered)
+	},
+}
+
+func printDiscoveredAgents(agents []agent.Agent) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSTATE\tPORT OFFSET\tLOCATION")
+
+	for _, a := range agents {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", a.Name, a.AgentType, a.State, a.PortOffset, agent.GetAgentLocation(&a))
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,70 @@
+package agents
+
+import (
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/spf13/cobra"
+)
+
+var healthCheckFlags = []string{
+	"healthcheck",
+	"healthcheck-cmd",
+	"healthcheck-interval",
+	"healthcheck-timeout",
+	"healthcheck-start-period",
+	"healthcheck-retries",
+}
+
+func addHealthCheckFlags(cmd *cobra.Command, healthCheck *agent.AgentHealthCheck) {
+	cmd.Flags().StringVar(&healthCheck.Type, "healthcheck", "", "The SSM Agent Docker Health Check [none|process|udp|command], defaults to process")
+	cmd.Flags().StringVar(&healthCheck.Command, "healthcheck-cmd", "", "The shell command run by the command health check")
+	cmd.Flags().IntVar(&healthCheck.Interval, "healthcheck-interval", 0, "Seconds between health checks, 0 for the default of 30")
+	cmd.Flags().IntVar(&healthCheck.Timeout, "healthcheck-timeout", 0, "Seconds a health check can run, 0 for the default of 10")
+	cmd.Flags().IntVar(&healthCheck.StartPeriod, "healthcheck-start-period", 0, "Seconds failed health checks are ignored after start, 0 for the default of 120")
+	cmd.Flags().IntVar(&healthCheck.Retries, "healthcheck-retries", 0, "Failed health checks before the agent is unhealthy, 0 for the default of 3")
+}
+
+// getUpdatedHealthCheck applies the changed health check flags, parsed into
+// flagValues by addHealthCheckFlags, to the health check of the agent,
+// returning nil if none of them were set.
+func getUpdatedHealthCheck(cmd *cobra.Command, flagValues agent.AgentHealthCheck, agentName string) (*agent.AgentHealthCheck, error) {
+	changed := false
+	for _, flag := range healthCheckFlags {
+		if cmd.Flags().Changed(flag) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil, nil
+	}
+
+	a, err := agent.GetAgent(agentName)
+	if err != nil {
+		return nil, err
+	}
+	healthCheck := a.HealthCheck
+
+	if cmd.Flags().Changed("healthcheck") {
+		healthCheck.Type = flagValues.Type
+		if healthCheck.Type != agent.HealthCheckCommand {
+			healthCheck.Command = ""
+		}
+	}
+	if cmd.Flags().Changed("healthcheck-cmd") {
+		healthCheck.Command = flagValues.Command
+	}
+	if cmd.Flags().Changed("healthcheck-interval") {
+		healthCheck.Interval = flagValues.Interval
+	}
+	if cmd.Flags().Changed("healthcheck-timeout") {
+		healthCheck.Timeout = flagValues.Timeout
+	}
+	if cmd.Flags().Changed("healthcheck-start-period") {
+		healthCheck.StartPeriod = flagValues.StartPeriod
+	}
+	if cmd.Flags().Changed("healthcheck-retries") {
+		healthCheck.Retries = flagValues.Retries
+	}
+
+	return &healthCheck, nil
+}
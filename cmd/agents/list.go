@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"text/tabwriter"
 
 	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
 	"github.com/spf13/cobra"
 )
 
+var listCmdJSONFlag bool
+
 func init() {
 	Cmd.AddCommand(listCmd)
 }
@@ -17,16 +21,40 @@ func init() {
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all agents",
+	Long:  `Lists all agents and the host each one is placed on`,
 	Run: func(cmd *cobra.Command, args []string) {
 		agent.LoadAgents(gui.MainApp.Preferences())
 
-		b, err := json.MarshalIndent(agent.AllAgents.Agents, "", "    ")
+		if listCmdJSONFlag {
+			b, err := json.MarshalIndent(agent.AllAgents.Agents, "", "    ")
+
+			if err != nil {
+				log.Printf("Error listing agent with error %s\r\n", err.Error())
+				return
+			}
 
-		if err != nil {
-			log.Printf("Error listing agent with error %s\r\n", err.Error())
+			fmt.Println(string(b))
 			return
 		}
 
-		fmt.Println(string(b))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTYPE\tSTATE\tPORT OFFSET\tENDPOINT\tHOST")
+
+		for idx := range agent.AllAgents.Agents {
+			a := &agent.AllAgents.Agents[idx]
+
+			endpoint := a.Endpoint
+			if endpoint == "" {
+				endpoint = agent.LocalEndpointName
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", a.Name, a.AgentType, a.State, a.PortOffset, endpoint, agent.GetAgentHost(a))
+		}
+
+		w.Flush()
 	},
 }
+
+func init() {
+	listCmd.Flags().BoolVar(&listCmdJSONFlag, "json", false, "Print the full agent records as JSON")
+}
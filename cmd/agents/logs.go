@@ -0,0 +1,52 @@
+package agents
+
+import (
+	"log"
+	"os"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var logsCmdFollowFlag bool
+var logsCmdTailFlag string
+var logsCmdSinceFlag string
+var logsCmdTimestampsFlag bool
+
+func init() {
+	Cmd.AddCommand(logsCmd)
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <name>",
+	Short: "Shows the logs of a ssm agent",
+	Long:  `Shows the container logs of a docker agent or the journal of a standalone agent`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+		err := agent.StreamAgentLogs(
+			args[0],
+			agent.LogOptions{
+				Follow:     logsCmdFollowFlag,
+				Tail:       logsCmdTailFlag,
+				Since:      logsCmdSinceFlag,
+				Timestamps: logsCmdTimestampsFlag,
+			},
+			os.Stdout,
+			os.Stderr,
+		)
+
+		if err != nil {
+			log.Printf("Error getting agent logs, with error %s\r\n", err.Error())
+			return
+		}
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsCmdFollowFlag, "follow", "f", false, "Follow the log output")
+	logsCmd.Flags().StringVarP(&logsCmdTailFlag, "tail", "n", "all", "Number of lines to show from the end of the logs")
+	logsCmd.Flags().StringVar(&logsCmdSinceFlag, "since", "", "Show logs since a timestamp or relative duration, like 1h")
+	logsCmd.Flags().BoolVarP(&logsCmdTimestampsFlag, "timestamps", "t", false, "Show timestamps")
+}
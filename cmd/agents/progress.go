@@ -0,0 +1,43 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+)
+
+const pullProgressBarWidth = 30
+
+// pullProgressBar renders the image pull progress on a single terminal line.
+type pullProgressBar struct {
+	tracker *agent.PullProgressTracker
+	started bool
+}
+
+func newPullProgressBar() *pullProgressBar {
+	return &pullProgressBar{tracker: agent.NewPullProgressTracker()}
+}
+
+func (p *pullProgressBar) Update(progress agent.PullProgress) {
+	p.tracker.Update(progress)
+
+	fraction, complete, layers := p.tracker.Progress()
+	filled := int(fraction * pullProgressBarWidth)
+
+	fmt.Printf("\rPulling image [%s%s] %3.0f%% %d/%d layers",
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", pullProgressBarWidth-filled),
+		fraction*100,
+		complete,
+		layers,
+	)
+	p.started = true
+}
+
+// Finish ends the progress line if anything was printed.
+func (p *pullProgressBar) Finish() {
+	if p.started {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,29 @@
+package agents
+
+import (
+	"log"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	Cmd.AddCommand(renameCmd)
+}
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <name> <newname>",
+	Short: "Renames a ssm agent",
+	Long:  `Renames a ssm agent, its container or service, its directories and its SSM Cloud server`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+		err := agent.RenameAgent(args[0], args[1], gui.MainApp.Preferences())
+
+		if err != nil {
+			log.Printf("Error renaming agent, with error %s\r\n", err.Error())
+			return
+		}
+	},
+}
@@ -0,0 +1,34 @@
+package agents
+
+import (
+	"log"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var restartCmdTimeoutFlag int
+
+func init() {
+	Cmd.AddCommand(restartCmd)
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart <name>",
+	Short: "Restarts a ssm agent",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+		err := agent.RestartAgent(args[0], restartCmdTimeoutFlag, gui.MainApp.Preferences())
+
+		if err != nil {
+			log.Printf("Error restarting agent, with error %s\r\n", err.Error())
+			return
+		}
+	},
+}
+
+func init() {
+	restartCmd.Flags().IntVarP(&restartCmdTimeoutFlag, "timeout", "t", agent.DefaultStopTimeout, "Seconds to wait for the agent to stop before killing it")
+}
@@ -0,0 +1,28 @@
+package agents
+
+import (
+	"log"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	Cmd.AddCommand(startCmd)
+}
+
+var startCmd = &cobra.Command{
+	Use:   "start <name>",
+	Short: "Starts a ssm agent",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+		err := agent.StartAgent(args[0], gui.MainApp.Preferences())
+
+		if err != nil {
+			log.Printf("Error starting agent, with error %s\r\n", err.Error())
+			return
+		}
+	},
+}
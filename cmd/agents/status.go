@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var statusCmdWatchFlag bool
+var statusCmdIntervalFlag time.Duration
+
+func init() {
+	Cmd.AddCommand(statusCmd)
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status [name]",
+	Short: "Shows the live status of agents",
+	Long:  `Shows the live runtime status of all agents, or a single agent. Exits with a non-zero code if any agent is unhealthy`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for {
+			agent.LoadAgents(gui.MainApp.Preferences())
+
+			agents := agent.AllAgents.Agents
+			if len(args) == 1 {
+				a, err := agent.GetAgent(args[0])
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				agents = []agent.Agent{*a}
+			}
+
+			if statusCmdWatchFlag {
+				fmt.Print("\033[H\033[2J")
+			}
+
+			healthy := printAgentStatusTable(agents)
+
+			if !statusCmdWatchFlag {
+				if !healthy {
+					os.Exit(1)
+				}
+				return
+			}
+
+			time.Sleep(statusCmdIntervalFlag)
+		}
+	},
+}
+
+func init() {
+	statusCmd.Flags().BoolVarP(&statusCmdWatchFlag, "watch", "w", false, "Keep refreshing the status")
+	statusCmd.Flags().DurationVarP(&statusCmdIntervalFlag, "interval", "i", 5*time.Second, "The refresh interval used with --watch")
+}
+
+// printAgentStatusTable prints the live status of the agents and returns
+// false if any of them is unhealthy.
+func printAgentStatusTable(agents []agent.Agent) bool {
+	allHealthy := true
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSTATE\tRUNTIME\tCHECK\tHEALTH\tUPTIME\tRESTARTS\tPID\tLIMITS\tHEALTHY")
+
+	for idx := range agents {
+		a := &agents[idx]
+
+		status, err := agent.GetAgentStatus(a.Name)
+		healthy := err == nil && status.IsHealthy(a)
+		if !healthy {
+			allHealthy = false
+		}
+
+		runtimeState := status.State
+		if status.SubState != "" {
+			runtimeState += "/" + status.SubState
+		}
+		if err != nil {
+			runtimeState = "error: " + err.Error()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			a.Name,
+			a.AgentType,
+			a.State,
+			runtimeState,
+			valueOrDash(a.HealthCheck.Type),
+			valueOrDash(status.Health),
+			status.Uptime(),
+			status.RestartCount,
+			status.PID,
+			valueOrDash(a.ResourceLimits()),
+			strconv.FormatBool(healthy),
+		)
+	}
+
+	w.Flush()
+	return allHealthy
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,34 @@
+package agents
+
+import (
+	"log"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var stopCmdTimeoutFlag int
+
+func init() {
+	Cmd.AddCommand(stopCmd)
+}
+
+var stopCmd = &cobra.Command{
+	Use:   "stop <name>",
+	Short: "Stops a ssm agent",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+		err := agent.StopAgent(args[0], stopCmdTimeoutFlag, gui.MainApp.Preferences())
+
+		if err != nil {
+			log.Printf("Error stopping agent, with error %s\r\n", err.Error())
+			return
+		}
+	},
+}
+
+func init() {
+	stopCmd.Flags().IntVarP(&stopCmdTimeoutFlag, "timeout", "t", agent.DefaultStopTimeout, "Seconds to wait for the agent to stop before killing it")
+}
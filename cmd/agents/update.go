@@ -0,0 +1,129 @@
+package agents
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"github.com/spf13/cobra"
+)
+
+var updateCmdPortOffsetFlag int
+var updateCmdMemoryFlag int
+var updateCmdCPUsFlag float64
+var updateCmdCPUSetFlag string
+var updateCmdMemoryReservationFlag int
+var updateCmdPidsLimitFlag int64
+var updateCmdRestartFlag string
+var updateCmdImageFlag string
+var updateCmdNetworkModeFlag string
+var updateCmdNetworkFlag string
+var updateCmdBindIPFlag string
+var updateCmdHealthCheck agent.AgentHealthCheck
+var updateCmdYesFlag bool
+
+func init() {
+	Cmd.AddCommand(updateCmd)
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update <name>",
+	Short: "Updates the settings of a ssm agent",
+	Long:  `Updates the settings of a ssm agent and applies them to the installed agent`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		opts := agent.UpdateAgentOptions{}
+		if cmd.Flags().Changed("portoffset") {
+			opts.PortOffset = &updateCmdPortOffsetFlag
+		}
+		if cmd.Flags().Changed("memory") {
+			opts.Memory = &updateCmdMemoryFlag
+		}
+		if cmd.Flags().Changed("cpus") {
+			opts.CPUs = &updateCmdCPUsFlag
+		}
+		if cmd.Flags().Changed("cpuset") {
+			opts.CPUSet = &updateCmdCPUSetFlag
+		}
+		if cmd.Flags().Changed("memory-reservation") {
+			opts.MemoryReservation = &updateCmdMemoryReservationFlag
+		}
+		if cmd.Flags().Changed("pids-limit") {
+			opts.PidsLimit = &updateCmdPidsLimitFlag
+		}
+		if cmd.Flags().Changed("restart") {
+			opts.RestartPolicy = &updateCmdRestartFlag
+		}
+		if cmd.Flags().Changed("image") {
+			opts.Image = &updateCmdImageFlag
+		}
+		if cmd.Flags().Changed("network-mode") {
+			opts.NetworkMode = &updateCmdNetworkModeFlag
+		}
+		if cmd.Flags().Changed("network") {
+			opts.NetworkName = &updateCmdNetworkFlag
+		}
+		if cmd.Flags().Changed("bind-ip") {
+			opts.BindIP = &updateCmdBindIPFlag
+		}
+
+		healthCheck, err := getUpdatedHealthCheck(cmd, updateCmdHealthCheck, args[0])
+		if err != nil {
+			log.Printf("Error updating agent, with error %s\r\n", err.Error())
+			return
+		}
+		opts.HealthCheck = healthCheck
+
+		plan, err := agent.PlanAgentUpdate(args[0], opts)
+		if err != nil {
+			log.Printf("Error updating agent, with error %s\r\n", err.Error())
+			return
+		}
+
+		if len(plan.Changes) == 0 {
+			fmt.Println("No changes to apply")
+			return
+		}
+
+		for _, change := range plan.Changes {
+			fmt.Println("Change:", change)
+		}
+
+		if plan.IsUnsafe() && !updateCmdYesFlag {
+			for _, warning := range plan.Warnings {
+				fmt.Println("Warning:", warning)
+			}
+
+			if !utils.AskForConfirmation("Apply these changes?") {
+				fmt.Println("Update cancelled")
+				return
+			}
+		}
+
+		err = agent.UpdateAgent(args[0], opts, gui.MainApp.Preferences())
+		if err != nil {
+			log.Printf("Error updating agent, with error %s\r\n", err.Error())
+			return
+		}
+	},
+}
+
+func init() {
+	updateCmd.Flags().IntVarP(&updateCmdPortOffsetFlag, "portoffset", "p", 0, "The SSM Agent Port Offset")
+	updateCmd.Flags().IntVarP(&updateCmdMemoryFlag, "memory", "m", 0, "The SSM Agent Docker Memory Limit")
+	updateCmd.Flags().Float64Var(&updateCmdCPUsFlag, "cpus", 0, "The SSM Agent Docker CPU Limit, 0 for no limit")
+	updateCmd.Flags().StringVar(&updateCmdCPUSetFlag, "cpuset", "", "The CPUs the SSM Agent Docker container can use, empty for all")
+	updateCmd.Flags().IntVar(&updateCmdMemoryReservationFlag, "memory-reservation", 0, "The SSM Agent Docker Memory Reservation in GB, 0 for none")
+	updateCmd.Flags().Int64Var(&updateCmdPidsLimitFlag, "pids-limit", 0, "The SSM Agent Docker Pids Limit, 0 for no limit")
+	updateCmd.Flags().StringVar(&updateCmdRestartFlag, "restart", agent.DefaultRestartPolicy, "The SSM Agent Docker Restart Policy [no|always|unless-stopped|on-failure[:N]]")
+	updateCmd.Flags().StringVar(&updateCmdImageFlag, "image", "", "The SSM Agent Docker Image with a tag or digest")
+	updateCmd.Flags().StringVar(&updateCmdNetworkModeFlag, "network-mode", "", "The SSM Agent Docker Network Mode [bridge|host|user|external]")
+	updateCmd.Flags().StringVar(&updateCmdNetworkFlag, "network", "", "The SSM Agent Docker Network Name, empty to clear it")
+	updateCmd.Flags().StringVar(&updateCmdBindIPFlag, "bind-ip", "", "The host IP address the SSM Agent ports are published on, empty for all addresses")
+	addHealthCheckFlags(updateCmd, &updateCmdHealthCheck)
+	updateCmd.Flags().BoolVarP(&updateCmdYesFlag, "yes", "y", false, "Apply unsafe changes without asking")
+}
@@ -0,0 +1,62 @@
+package agents
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var upgradeCmdAllFlag bool
+var upgradeCmdImageFlag string
+var upgradeCmdHealthTimeoutFlag time.Duration
+
+func init() {
+	Cmd.AddCommand(upgradeCmd)
+}
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade <name>|--all",
+	Short: "Upgrades ssm agents to a new release",
+	Long:  `Upgrades a ssm agent to a new release. Docker agents get a new container from the pulled image, the old container is restored if the new one does not become healthy`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if upgradeCmdAllFlag && len(args) > 0 {
+			return errors.New("use either an agent name or --all")
+		}
+		if !upgradeCmdAllFlag && len(args) != 1 {
+			return errors.New("requires an agent name or --all")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		agentNames := args
+		if upgradeCmdAllFlag {
+			agentNames = agent.GetContainerAgentNames()
+		}
+
+		for _, agentName := range agentNames {
+			progressBar := newPullProgressBar()
+			err := agent.UpgradeAgent(agentName, agent.UpgradeOptions{
+				Image:          upgradeCmdImageFlag,
+				HealthTimeout:  upgradeCmdHealthTimeoutFlag,
+				OnPullProgress: progressBar.Update,
+			}, gui.MainApp.Preferences())
+			progressBar.Finish()
+
+			if err != nil {
+				log.Printf("Error upgrading agent %s, with error %s\r\n", agentName, err.Error())
+			}
+		}
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeCmdAllFlag, "all", false, "Upgrade every docker agent")
+	upgradeCmd.Flags().StringVar(&upgradeCmdImageFlag, "image", "", "The docker image to upgrade to, defaults to the agent image")
+	upgradeCmd.Flags().DurationVar(&upgradeCmdHealthTimeoutFlag, "health-timeout", agent.DefaultUpgradeHealthTimeout, "How long the upgraded agent has to become healthy before it is rolled back")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
+	"github.com/spf13/cobra"
+)
+
+var applyCmdFileFlag string
+var applyCmdPruneFlag bool
+var applyCmdYesFlag bool
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Creates, updates and deletes agents to match an agent manifest",
+	Long:  `Compares a YAML or JSON agent manifest with the agent inventory and applies the changes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefs := gui.MainApp.Preferences()
+		agent.LoadAgents(prefs)
+
+		changes, err := planManifest(applyCmdFileFlag, applyCmdPruneFlag, prefs)
+		if err != nil {
+			return fmt.Errorf("planning manifest: %w", err)
+		}
+
+		printManifestChanges(changes)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		if !applyCmdYesFlag && !utils.AskForConfirmation("Apply these changes?") {
+			fmt.Println("Apply cancelled")
+			return nil
+		}
+
+		err = agent.ApplyManifestChanges(changes, prefs)
+		if err != nil {
+			return fmt.Errorf("applying manifest: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	applyCmd.Flags().StringVarP(&applyCmdFileFlag, "file", "f", "", "The agent manifest file")
+	applyCmd.Flags().BoolVar(&applyCmdPruneFlag, "prune", false, "Delete agents that are not in the manifest")
+	applyCmd.Flags().BoolVarP(&applyCmdYesFlag, "yes", "y", false, "Apply without asking")
+
+	applyCmd.MarkFlagRequired("file")
+	applyCmd.MarkFlagFilename("file", "yaml", "yml", "json")
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var endpointHostFlag string
+var endpointTLSCACertFlag string
+var endpointTLSCertFlag string
+var endpointTLSKeyFlag string
+
+func init() {
+	Cmd.AddCommand(endpointCmd)
+	endpointCmd.AddCommand(endpointSetCmd)
+	endpointCmd.AddCommand(endpointRemoveCmd)
+	endpointCmd.AddCommand(endpointListCmd)
+}
+
+var endpointCmd = &cobra.Command{
+	Use:   "endpoint",
+	Short: "Manages the docker hosts agents can be placed on",
+}
+
+var endpointSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Adds or updates a docker endpoint",
+	Long:  `Adds or updates a docker endpoint. The host is a unix socket, a tcp address with optional TLS client certs, or an ssh address`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		err := agent.SetDockerEndpoint(agent.DockerEndpoint{
+			Name:      args[0],
+			Host:      endpointHostFlag,
+			TLSCACert: endpointTLSCACertFlag,
+			TLSCert:   endpointTLSCertFlag,
+			TLSKey:    endpointTLSKeyFlag,
+		}, gui.MainApp.Preferences())
+		if err != nil {
+			log.Printf("Error saving docker endpoint, with error %s\r\n", err.Error())
+		}
+	},
+}
+
+var endpointRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Removes a docker endpoint that no agent uses",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		err := agent.RemoveDockerEndpoint(args[0], gui.MainApp.Preferences())
+		if err != nil {
+			log.Printf("Error removing docker endpoint, with error %s\r\n", err.Error())
+		}
+	},
+}
+
+var endpointListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the docker endpoints",
+	Run: func(cmd *cobra.Command, args []string) {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tHOST\tTLS")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", agent.LocalEndpointName, "from environment", "-")
+
+		for _, endpoint := range agent.AllDockerEndpoints {
+			tls := "-"
+			if endpoint.TLSCert != "" {
+				tls = endpoint.TLSCert
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", endpoint.Name, endpoint.Host, tls)
+		}
+
+		w.Flush()
+	},
+}
+
+func init() {
+	endpointSetCmd.Flags().StringVarP(&endpointHostFlag, "host", "H", "", "The docker host [unix:///path|tcp://host:port|ssh://user@host]")
+	endpointSetCmd.Flags().StringVar(&endpointTLSCACertFlag, "tlscacert", "", "The CA certificate used to verify a tcp docker host")
+	endpointSetCmd.Flags().StringVar(&endpointTLSCertFlag, "tlscert", "", "The TLS client certificate for a tcp docker host")
+	endpointSetCmd.Flags().StringVar(&endpointTLSKeyFlag, "tlskey", "", "The TLS client key for a tcp docker host")
+
+	endpointSetCmd.MarkFlagRequired("host")
+	endpointSetCmd.MarkFlagFilename("tlscacert")
+	endpointSetCmd.MarkFlagFilename("tlscert")
+	endpointSetCmd.MarkFlagFilename("tlskey")
+}
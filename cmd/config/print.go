@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -19,7 +21,17 @@ var printCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		prefs := gui.MainApp.Preferences()
 		fmt.Println("Config File:", filepath.Join(gui.MainApp.Storage().RootURI().Path(), "preferences.json"))
-		fmt.Println("SSM Cloud URL: ", prefs.String("ssmurl"))
+		fmt.Println("SSM Cloud URL: ", utils.GetSSMURL(prefs))
 		fmt.Println("SSM Cloud API Key: ", prefs.String("ssmapikey"))
+		fmt.Println("Docker Image: ", agent.GetDefaultAgentImage(prefs))
+		fmt.Println("Docker Registry Username: ", prefs.String("dockerregistryusername"))
+
+		err := agent.LoadDockerEndpoints(prefs)
+		if err != nil {
+			fmt.Println("Docker Endpoints: ", err)
+		}
+		for _, endpoint := range agent.AllDockerEndpoints {
+			fmt.Println("Docker Endpoint: ", endpoint.Name, endpoint.Host)
+		}
 	},
 }
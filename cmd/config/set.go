@@ -1,12 +1,19 @@
 package config
 
 import (
+	"log"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
 	"github.com/spf13/cobra"
 )
 
 var ssmUrlFlag string
 var ssmApiKeyFlag string
+var dockerImageFlag string
+var registryUsernameFlag string
+var registryPasswordFlag string
 
 func init() {
 
@@ -18,13 +25,44 @@ var setCmd = &cobra.Command{
 	Short: "Updates the manager config",
 	Long:  `Updates the manager config`,
 	Run: func(cmd *cobra.Command, args []string) {
-		gui.MainApp.Preferences().SetString("ssmurl", ssmUrlFlag)
-		gui.MainApp.Preferences().SetString("ssmapikey", ssmApiKeyFlag)
+		if utils.SkipForDryRun("save manager config") {
+			return
+		}
+
+		prefs := gui.MainApp.Preferences()
+
+		// The image is checked first so an invalid image saves nothing.
+		if cmd.Flags().Changed("docker-image") {
+			err := agent.ValidateImageReference(dockerImageFlag)
+			if err != nil {
+				log.Printf("Error saving manager config, with error %s\r\n", err.Error())
+				return
+			}
+		}
+
+		if cmd.Flags().Changed("ssmurl") {
+			prefs.SetString("ssmurl", ssmUrlFlag)
+		}
+		if cmd.Flags().Changed("ssmapikey") {
+			prefs.SetString("ssmapikey", ssmApiKeyFlag)
+		}
+		if cmd.Flags().Changed("docker-image") {
+			prefs.SetString("dockerimage", dockerImageFlag)
+		}
+		if cmd.Flags().Changed("registry-username") {
+			prefs.SetString("dockerregistryusername", registryUsernameFlag)
+		}
+		if cmd.Flags().Changed("registry-password") {
+			prefs.SetString("dockerregistrypassword", registryPasswordFlag)
+		}
 	},
 }
 
 func init() {
-	setCmd.Flags().StringVarP(&ssmUrlFlag, "ssmurl", "s", "https://ssmcloud.hostxtra.co.uk", "The SSM Cloud URL")
+	setCmd.Flags().StringVarP(&ssmUrlFlag, "ssmurl", "s", utils.DefaultSSMURL, "The SSM Cloud URL")
 	setCmd.Flags().StringVarP(&ssmApiKeyFlag, "ssmapikey", "a", "", "The SSM Cloud API Key")
+	setCmd.Flags().StringVar(&dockerImageFlag, "docker-image", agent.DefaultAgentImage, "The default SSM Agent docker image, with a tag or digest")
+	setCmd.Flags().StringVar(&registryUsernameFlag, "registry-username", "", "The docker registry username used to pull the agent image")
+	setCmd.Flags().StringVar(&registryPasswordFlag, "registry-password", "", "The docker registry password used to pull the agent image")
 
 }
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmdIntervalFlag time.Duration
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Runs the headless reconciliation daemon",
+	Long:  `Keeps agents that are stored as running actually running, restarting crashed agents and recreating missing ones`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		log.Printf("Starting daemon, checking agents every %s\r\n", daemonCmdIntervalFlag)
+		agent.NewReconciler(gui.MainApp.Preferences()).Run(ctx, daemonCmdIntervalFlag)
+	},
+}
+
+func init() {
+	daemonCmd.Flags().DurationVarP(&daemonCmdIntervalFlag, "interval", "i", 30*time.Second, "How often agents are checked")
+}
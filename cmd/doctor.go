@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmdFixFlag bool
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Checks the agent inventory against what is installed",
+	Long:  `Compares every stored agent with docker, systemd, the filesystem and SSM Cloud and reports orphaned, missing and mismatched resources`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefs := gui.MainApp.Preferences()
+		agent.LoadAgents(prefs)
+
+		issues, err := agent.RunDoctor(prefs)
+		if err != nil {
+			return fmt.Errorf("running doctor: %w", err)
+		}
+
+		if len(issues) == 0 {
+			fmt.Println("No issues found")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "AGENT\tKIND\tFIXABLE\tISSUE")
+		for _, issue := range issues {
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", issue.Agent, issue.Kind, issue.Fix != nil, issue.Message)
+		}
+		w.Flush()
+
+		if !doctorCmdFixFlag {
+			return fmt.Errorf("found %d issues", len(issues))
+		}
+
+		fixErrors := agent.FixDoctorIssues(issues, prefs)
+		for _, err := range fixErrors {
+			fmt.Fprintln(os.Stderr, "Error fixing issue:", err)
+		}
+
+		if len(fixErrors) > 0 {
+			return fmt.Errorf("%d issues could not be fixed", len(fixErrors))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorCmdFixFlag, "fix", false, "Repair the issues that can be fixed")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var exportCmdOutputFlag string
+var exportCmdFormatFlag string
+var exportCmdIncludeSecretsFlag bool
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the agent inventory and manager config",
+	Long:  `Exports all agents and the manager config as a versioned JSON or YAML document. Api keys are redacted unless --include-secrets is set`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefs := gui.MainApp.Preferences()
+		agent.LoadAgents(prefs)
+
+		b, err := agent.ExportInventory(prefs, exportCmdFormatFlag, exportCmdIncludeSecretsFlag)
+		if err != nil {
+			return fmt.Errorf("exporting inventory: %w", err)
+		}
+
+		if exportCmdOutputFlag == "" {
+			fmt.Println(string(b))
+			return nil
+		}
+
+		err = os.WriteFile(exportCmdOutputFlag, b, 0600)
+		if err != nil {
+			return fmt.Errorf("exporting inventory: %w", err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportCmdOutputFlag, "output", "o", "", "The file to write, defaults to stdout")
+	exportCmd.Flags().StringVar(&exportCmdFormatFlag, "format", "json", "The export format [json|yaml]")
+	exportCmd.Flags().BoolVar(&exportCmdIncludeSecretsFlag, "include-secrets", false, "Include api keys in the export")
+}
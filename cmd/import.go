@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var importCmdFileFlag string
+var importCmdModeFlag string
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Imports an exported agent inventory and manager config",
+	Long:  `Imports a JSON or YAML document written by export. Only the agent records are imported, no agents are installed or removed`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefs := gui.MainApp.Preferences()
+		agent.LoadAgents(prefs)
+
+		b, err := os.ReadFile(importCmdFileFlag)
+		if err != nil {
+			return fmt.Errorf("importing inventory: %w", err)
+		}
+
+		doc, err := agent.ParseInventory(b)
+		if err != nil {
+			return fmt.Errorf("importing inventory: %w", err)
+		}
+
+		err = agent.ImportInventory(doc, importCmdModeFlag, prefs)
+		if err != nil {
+			return fmt.Errorf("importing inventory: %w", err)
+		}
+
+		fmt.Printf("Imported %d agents\n", len(doc.Agents))
+
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importCmdFileFlag, "file", "f", "", "The inventory file to import")
+	importCmd.Flags().StringVar(&importCmdModeFlag, "mode", agent.ImportMerge, "How to import the agents [merge|replace]")
+
+	importCmd.MarkFlagRequired("file")
+	importCmd.MarkFlagFilename("file", "yaml", "yml", "json")
+}
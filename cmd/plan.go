@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/spf13/cobra"
+)
+
+var planCmdFileFlag string
+var planCmdPruneFlag bool
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Shows the changes needed to match an agent manifest",
+	Long:  `Compares a YAML or JSON agent manifest with the agent inventory and shows the agents that would be created, updated and deleted`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		agent.LoadAgents(gui.MainApp.Preferences())
+
+		changes, err := planManifest(planCmdFileFlag, planCmdPruneFlag, gui.MainApp.Preferences())
+		if err != nil {
+			return fmt.Errorf("planning manifest: %w", err)
+		}
+
+		printManifestChanges(changes)
+
+		return nil
+	},
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&planCmdFileFlag, "file", "f", "", "The agent manifest file")
+	planCmd.Flags().BoolVar(&planCmdPruneFlag, "prune", false, "Delete agents that are not in the manifest")
+
+	planCmd.MarkFlagRequired("file")
+	planCmd.MarkFlagFilename("file", "yaml", "yml", "json")
+}
+
+func planManifest(manifestPath string, prune bool, prefs fyne.Preferences) ([]agent.ManifestChange, error) {
+	manifest, err := agent.LoadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return agent.PlanManifest(manifest, prune, prefs)
+}
+
+func printManifestChanges(changes []agent.ManifestChange) {
+	if len(changes) == 0 {
+		fmt.Println("No changes, the agents match the manifest")
+		return
+	}
+
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Action]++
+
+		fmt.Printf("%s %s: %s\n", change.Action, change.Agent, strings.Join(change.Details, ", "))
+		for _, warning := range change.Warnings {
+			fmt.Printf("    warning: %s\n", warning)
+		}
+	}
+
+	fmt.Printf("\nPlan: %d to create, %d to update, %d to delete\n",
+		counts[agent.ChangeCreate],
+		counts[agent.ChangeUpdate],
+		counts[agent.ChangeDelete],
+	)
+}
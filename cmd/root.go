@@ -4,16 +4,31 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/SatisfactoryServerManager/SSMAgentManager/agent"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/cmd/agents"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/cmd/config"
 	"github.com/SatisfactoryServerManager/SSMAgentManager/gui"
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
 	"github.com/spf13/cobra"
 )
 
+var dryRunFlag bool
+
 var rootCmd = &cobra.Command{
 	Use:   "ssmagentmanager",
 	Short: "SSM Agent Manager",
 	Long:  "SSM Agent Manager to manage installed SSM Agents",
+	// Errors are printed once by Execute, and usage is only shown for
+	// invalid flags and arguments rather than for failed commands.
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
+
+		// Loading migrates and saves the inventory, so it has to wait for
+		// the dry run flag.
+		utils.SetDryRun(dryRunFlag)
+		agent.LoadAgents(gui.MainApp.Preferences())
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			gui.SetupGUI()
@@ -22,13 +37,32 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false, "Show the changes that would be made without making them")
+
 	rootCmd.AddCommand(config.Cmd)
 	rootCmd.AddCommand(agents.Cmd)
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	// The planned actions are printed even when the command failed, since
+	// the actions before the failure are still useful.
+	err := rootCmd.Execute()
+	printPlannedActions()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
+
+func printPlannedActions() {
+	if !utils.IsDryRun() {
+		return
+	}
+
+	actions := utils.GetPlannedActions()
+	fmt.Printf("Dry run, %d planned actions:\r\n", len(actions))
+	for _, action := range actions {
+		fmt.Printf("  - %s\r\n", action)
+	}
+}
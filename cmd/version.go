@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/SatisfactoryServerManager/SSMAgentManager/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +16,6 @@ var versionCmd = &cobra.Command{
 	Short: "Version Number",
 	Long:  `Version Number`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("v0.0.1")
+		fmt.Println(utils.Version)
 	},
 }
@@ -2,8 +2,12 @@ package gui
 
 import (
 	"errors"
+	"fmt"
 	"image/color"
 	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -82,6 +86,9 @@ var MainWindow fyne.Window
 var MainApp fyne.App
 var MainTabs *container.AppTabs
 
+// AgentActionLock is held while an agent action runs in the background.
+var AgentActionLock sync.Mutex
+
 func emptyValidator(s string) (err error) {
 	if s == "" {
 		return errors.New("empty string")
@@ -107,7 +114,12 @@ func SetupTabs() {
 		for {
 			select {
 			case <-ticker.C:
-				RefreshTabs()
+				// Skip the refresh while an agent is being changed in the
+				// background, reloading would replace the agent it is using.
+				if AgentActionLock.TryLock() {
+					RefreshTabs()
+					AgentActionLock.Unlock()
+				}
 			case <-quit:
 				ticker.Stop()
 				return
@@ -118,18 +130,46 @@ func SetupTabs() {
 
 func RefreshTabs() {
 	agent.LoadAgents(MainApp.Preferences())
+	agent.RefreshAgentHealth(MainApp.Preferences())
 	var tabItems = []*container.TabItem{
 		container.NewTabItem("Home", BuildHomeTabContent()),
 	}
 
-	for _, a := range agent.AllAgents.Agents {
-		tabItems = append(tabItems, a.GetAgentTabItem(func(agentName string) func() {
-			return func() {
-				err := agent.DeleteAgent(agentName, MainApp.Preferences())
-				if err != nil {
-					dialog.NewError(err, MainWindow).Show()
+	for idx := range agent.AllAgents.Agents {
+		a := &agent.AllAgents.Agents[idx]
+		tabItems = append(tabItems, a.GetAgentTabItem(agent.AgentTabHandlers{
+			Delete: func(agentName string) func() {
+				return func() {
+					OpenDeleteAgentDialog(agentName)
 				}
-			}
+			},
+			Start: func(agentName string) func() {
+				return func() {
+					runAgentActionInBackground(func() error {
+						return agent.StartAgent(agentName, MainApp.Preferences())
+					})
+				}
+			},
+			Stop: func(agentName string) func() {
+				return func() {
+					runAgentActionInBackground(func() error {
+						return agent.StopAgent(agentName, agent.DefaultStopTimeout, MainApp.Preferences())
+					})
+				}
+			},
+			Restart: func(agentName string) func() {
+				return func() {
+					runAgentActionInBackground(func() error {
+						return agent.RestartAgent(agentName, agent.DefaultStopTimeout, MainApp.Preferences())
+					})
+				}
+			},
+			DockerSettings: func(agentName string) func() {
+				return func() {
+					OpenDockerSettingsDialog(agentName)
+				}
+			},
+			Update: UpdateAgent,
 		}))
 	}
 
@@ -138,6 +178,63 @@ func RefreshTabs() {
 	MainTabs.Refresh()
 }
 
+// UpdateAgent applies the agent tab form, renaming the agent first if the
+// name changed and asking for confirmation when the changes are unsafe.
+func UpdateAgent(agentName string, newName string, opts agent.UpdateAgentOptions) {
+	runAgentActionInBackground(func() error {
+		if newName != agentName {
+			err := agent.RenameAgent(agentName, newName, MainApp.Preferences())
+			if err != nil {
+				return err
+			}
+			agentName = newName
+		}
+
+		plan, err := agent.PlanAgentUpdate(agentName, opts)
+		if err != nil || len(plan.Changes) == 0 {
+			return err
+		}
+
+		if !plan.IsUnsafe() {
+			return agent.UpdateAgent(agentName, opts, MainApp.Preferences())
+		}
+
+		// The update is run as a new action once confirmed, so the lock is
+		// not held while the dialog is open.
+		message := "Applying " + strings.Join(plan.Changes, ", ") + " is unsafe:\n" + strings.Join(plan.Warnings, "\n") + "\n\nContinue?"
+		dialog.NewConfirm("Update Agent", message, func(t bool) {
+			if t {
+				runAgentActionInBackground(func() error {
+					return agent.UpdateAgent(agentName, opts, MainApp.Preferences())
+				})
+			}
+		}, MainWindow).Show()
+		return nil
+	})
+}
+
+// runAgentAction shows the error from an agent action and refreshes the tabs
+// so the new agent state is shown.
+func runAgentAction(err error) {
+	if err != nil {
+		dialog.NewError(err, MainWindow).Show()
+		log.Printf("Error running agent action, with error %s\r\n", err.Error())
+	}
+	RefreshTabs()
+}
+
+// runAgentActionInBackground runs an agent action off the UI thread while
+// holding AgentActionLock, so the periodic tab refresh does not reload the
+// inventory while the action is using an agent from it.
+func runAgentActionInBackground(fn func() error) {
+	go func() {
+		AgentActionLock.Lock()
+		defer AgentActionLock.Unlock()
+
+		runAgentAction(fn())
+	}()
+}
+
 func SetupGUI() {
 
 	MainApp.Settings().SetTheme(&myTheme{})
@@ -150,6 +247,208 @@ func SetupGUI() {
 	MainWindow.SetContent(content)
 	MainWindow.Resize(fyne.NewSize(800, 600))
 	MainWindow.ShowAndRun()
+
+	agent.CloseDockerClients()
+}
+
+// newResourceLimitItems returns the form items for the docker resource limits
+// and a function that reads them back.
+func newResourceLimitItems(resources agent.AgentResources) ([]*widget.FormItem, func() (agent.AgentResources, error)) {
+	AgentCPUsBox := widget.NewEntry()
+	AgentCPUsBox.SetPlaceHolder("No limit")
+	if resources.CPUs > 0 {
+		AgentCPUsBox.SetText(strconv.FormatFloat(resources.CPUs, 'f', -1, 64))
+	}
+
+	AgentCPUSetBox := widget.NewEntry()
+	AgentCPUSetBox.SetPlaceHolder("All CPUs, or a list like 0-3")
+	AgentCPUSetBox.SetText(resources.CPUSet)
+
+	AgentMemoryReservationBox := customwidgets.NewNumericalEntry()
+	AgentMemoryReservationBox.SetValue(resources.MemoryReservation)
+
+	AgentPidsLimitBox := customwidgets.NewNumericalEntry()
+	AgentPidsLimitBox.SetValue(int(resources.PidsLimit))
+
+	formItems := []*widget.FormItem{
+		{Text: "Agent CPUs:", Widget: AgentCPUsBox},
+		{Text: "Agent CPU Set:", Widget: AgentCPUSetBox},
+		{Text: "Agent Memory Reservation (GB):", Widget: AgentMemoryReservationBox},
+		{Text: "Agent Pids Limit:", Widget: AgentPidsLimitBox},
+	}
+
+	getResources := func() (agent.AgentResources, error) {
+		resources := agent.AgentResources{
+			CPUSet: AgentCPUSetBox.Text,
+		}
+
+		if AgentCPUsBox.Text != "" {
+			cpus, err := strconv.ParseFloat(AgentCPUsBox.Text, 64)
+			if err != nil {
+				return resources, errors.New("agent cpus must be a number")
+			}
+			resources.CPUs = cpus
+		}
+
+		resources.MemoryReservation, _ = AgentMemoryReservationBox.GetValue()
+
+		pidsLimit, _ := AgentPidsLimitBox.GetValue()
+		resources.PidsLimit = int64(pidsLimit)
+
+		return resources, nil
+	}
+
+	return formItems, getResources
+}
+
+func newRestartPolicyEntry(restartPolicy string) *widget.SelectEntry {
+	AgentRestartPolicyBox := widget.NewSelectEntry(agent.RestartPolicies)
+	AgentRestartPolicyBox.SetPlaceHolder("on-failure:5")
+	AgentRestartPolicyBox.SetText(restartPolicy)
+	return AgentRestartPolicyBox
+}
+
+// newNetworkItems returns the form items for the docker network settings and
+// a function that reads them back.
+func newNetworkItems(network agent.AgentNetwork) ([]*widget.FormItem, func() agent.AgentNetwork) {
+	AgentNetworkNameBox := widget.NewEntry()
+	AgentNetworkNameBox.SetPlaceHolder("Network name")
+	AgentNetworkNameBox.SetText(network.Name)
+
+	AgentNetworkModeSelect := widget.NewSelect(agent.NetworkModes, func(mode string) {
+		if mode == agent.NetworkUser || mode == agent.NetworkExternal {
+			AgentNetworkNameBox.Enable()
+		} else {
+			AgentNetworkNameBox.SetText("")
+			AgentNetworkNameBox.Disable()
+		}
+	})
+	AgentNetworkModeSelect.SetSelected(network.GetMode())
+
+	AgentBindIPBox := widget.NewEntry()
+	AgentBindIPBox.SetPlaceHolder("All addresses")
+	AgentBindIPBox.SetText(network.BindIP)
+
+	formItems := []*widget.FormItem{
+		{Text: "Agent Network:", Widget: container.NewGridWithColumns(2, AgentNetworkModeSelect, AgentNetworkNameBox)},
+		{Text: "Agent Bind IP:", Widget: AgentBindIPBox},
+	}
+
+	getNetwork := func() agent.AgentNetwork {
+		return agent.AgentNetwork{
+			Mode:   AgentNetworkModeSelect.Selected,
+			Name:   AgentNetworkNameBox.Text,
+			BindIP: AgentBindIPBox.Text,
+		}
+	}
+
+	return formItems, getNetwork
+}
+
+// newHealthCheckItems returns the form items for the docker health check and
+// a function that reads it back.
+func newHealthCheckItems(healthCheck agent.AgentHealthCheck) ([]*widget.FormItem, func() agent.AgentHealthCheck) {
+	AgentHealthCheckCommandBox := widget.NewEntry()
+	AgentHealthCheckCommandBox.SetPlaceHolder("Health check command")
+	AgentHealthCheckCommandBox.SetText(healthCheck.Command)
+
+	AgentHealthCheckSelect := widget.NewSelect(agent.HealthChecks, func(healthCheckType string) {
+		if healthCheckType == agent.HealthCheckCommand {
+			AgentHealthCheckCommandBox.Enable()
+		} else {
+			AgentHealthCheckCommandBox.SetText("")
+			AgentHealthCheckCommandBox.Disable()
+		}
+	})
+	if healthCheck.Type != "" {
+		AgentHealthCheckSelect.SetSelected(healthCheck.Type)
+	} else {
+		AgentHealthCheckCommandBox.Disable()
+	}
+
+	AgentHealthCheckIntervalBox := customwidgets.NewNumericalEntry()
+	AgentHealthCheckIntervalBox.SetValue(healthCheck.Interval)
+
+	AgentHealthCheckRetriesBox := customwidgets.NewNumericalEntry()
+	AgentHealthCheckRetriesBox.SetValue(healthCheck.Retries)
+
+	formItems := []*widget.FormItem{
+		{Text: "Agent Health Check:", Widget: container.NewGridWithColumns(2, AgentHealthCheckSelect, AgentHealthCheckCommandBox)},
+		{Text: "Health Check Interval (s) / Retries:", Widget: container.NewGridWithColumns(2, AgentHealthCheckIntervalBox, AgentHealthCheckRetriesBox)},
+	}
+
+	getHealthCheck := func() agent.AgentHealthCheck {
+		updated := healthCheck
+		updated.Type = AgentHealthCheckSelect.Selected
+		updated.Command = AgentHealthCheckCommandBox.Text
+		updated.Interval, _ = AgentHealthCheckIntervalBox.GetValue()
+		updated.Retries, _ = AgentHealthCheckRetriesBox.GetValue()
+		return updated
+	}
+
+	return formItems, getHealthCheck
+}
+
+func OpenDockerSettingsDialog(agentName string) {
+	a, err := agent.GetAgent(agentName)
+	if err != nil {
+		runAgentAction(err)
+		return
+	}
+
+	resources := a.Resources
+	resources.MemoryReservation = resources.MemoryReservation / 1024 / 1024 / 1024
+
+	formItems, getResources := newResourceLimitItems(resources)
+
+	AgentRestartPolicyBox := newRestartPolicyEntry(a.RestartPolicy)
+	formItems = append(formItems, widget.NewFormItem("Agent Restart Policy:", AgentRestartPolicyBox))
+
+	AgentImageBox := widget.NewEntry()
+	AgentImageBox.SetText(a.Image)
+	AgentImageBox.Validator = agent.ValidateImageReference
+	formItems = append(formItems, widget.NewFormItem("Agent Image:", AgentImageBox))
+
+	AgentImageDigestLabel := widget.NewLabel(a.ImageDigest)
+	AgentImageDigestLabel.Wrapping = fyne.TextWrapBreak
+	formItems = append(formItems, widget.NewFormItem("Running Image:", AgentImageDigestLabel))
+
+	networkItems, getNetwork := newNetworkItems(a.Network)
+	formItems = append(formItems, networkItems...)
+
+	healthCheckItems, getHealthCheck := newHealthCheckItems(a.HealthCheck)
+	formItems = append(formItems, healthCheckItems...)
+
+	newDialog := dialog.NewForm("Docker Settings", "Update", "Cancel", formItems, func(t bool) {
+		if !t {
+			return
+		}
+
+		resources, err := getResources()
+		if err != nil {
+			runAgentAction(err)
+			return
+		}
+
+		network := getNetwork()
+		healthCheck := getHealthCheck()
+
+		UpdateAgent(agentName, agentName, agent.UpdateAgentOptions{
+			CPUs:              &resources.CPUs,
+			CPUSet:            &resources.CPUSet,
+			MemoryReservation: &resources.MemoryReservation,
+			PidsLimit:         &resources.PidsLimit,
+			RestartPolicy:     &AgentRestartPolicyBox.Text,
+			Image:             &AgentImageBox.Text,
+			NetworkMode:       &network.Mode,
+			NetworkName:       &network.Name,
+			BindIP:            &network.BindIP,
+			HealthCheck:       &healthCheck,
+		})
+	}, MainWindow)
+
+	newDialog.Resize(fyne.NewSize(600, 300))
+	newDialog.Show()
 }
 
 func OpenCreateAgentDialog() {
@@ -172,33 +471,62 @@ func OpenCreateAgentDialog() {
 	})
 
 	AgentFileLocationBtn.Importance = widget.HighImportance
-	AgentFileLocationBtn.Disable()
 
 	AgentMemoryBox := customwidgets.NewNumericalEntry()
 	AgentMemoryBox.Text = "0"
 
+	resourceItems, getResources := newResourceLimitItems(agent.AgentResources{})
+
+	AgentRestartPolicyBox := newRestartPolicyEntry(agent.DefaultRestartPolicy)
+
+	AgentEndpointSelect := widget.NewSelect(agent.GetDockerEndpointNames(), func(string) {})
+	AgentEndpointSelect.SetSelected(agent.LocalEndpointName)
+
 	AgentTypeSelect := widget.NewSelect([]string{
 		"Docker",
 		"Standalone",
-	}, func(val string) {
-		switch val {
-		case "Docker":
-			AgentFileLocationBtn.Disable()
-		case "Standalone":
-			AgentFileLocationBtn.Enable()
+	}, func(agentType string) {
+		// Standalone agents always run on the manager host.
+		if agentType == "Docker" {
+			AgentEndpointSelect.Enable()
+		} else {
+			AgentEndpointSelect.SetSelected(agent.LocalEndpointName)
+			AgentEndpointSelect.Disable()
 		}
 	})
 
+	AgentImageBox := widget.NewEntry()
+	AgentImageBox.SetPlaceHolder(agent.GetDefaultAgentImage(MainApp.Preferences()))
+
+	networkItems, getNetwork := newNetworkItems(agent.AgentNetwork{})
+
+	NoPullCheck := widget.NewCheck("Use the local docker image (no pull)", func(bool) {})
+
+	PreviewCheck := widget.NewCheck("Preview only (dry run)", func(bool) {})
+
 	var newDialog dialog.Dialog
 
 	formItems := []*widget.FormItem{
 		{Text: "Agent Name:", Widget: AgentNameBox},
 		{Text: "Agent Port Offset:", Widget: AgentPortBox},
 		{Text: "Agent Type:", Widget: AgentTypeSelect},
+		{Text: "Agent Docker Host:", Widget: AgentEndpointSelect},
 		{Text: "Agent Data Directory:", Widget: AgentFileLocationBtn},
 		{Text: "Agent Memory (GB):", Widget: AgentMemoryBox},
 	}
 
+	// The docker settings are folded away so the dialog fits in the window.
+	dockerItems := append([]*widget.FormItem{}, resourceItems...)
+	dockerItems = append(dockerItems, widget.NewFormItem("Agent Restart Policy:", AgentRestartPolicyBox))
+	dockerItems = append(dockerItems, widget.NewFormItem("Agent Image:", AgentImageBox))
+	dockerItems = append(dockerItems, networkItems...)
+
+	DockerSettingsAccordion := widget.NewAccordion(widget.NewAccordionItem("Docker Settings", widget.NewForm(dockerItems...)))
+
+	formItems = append(formItems, widget.NewFormItem("", DockerSettingsAccordion))
+	formItems = append(formItems, widget.NewFormItem("", NoPullCheck))
+	formItems = append(formItems, widget.NewFormItem("", PreviewCheck))
+
 	log.Println("Create Agent Button Pressed")
 
 	newDialog = dialog.NewForm("Create Agent", "Create", "Cancel", formItems, func(t bool) {
@@ -206,21 +534,51 @@ func OpenCreateAgentDialog() {
 			portOffset, _ := AgentPortBox.GetValue()
 			memory, _ := AgentMemoryBox.GetValue()
 
-			_, err := agent.CreateNewAgent(
-				AgentNameBox.Text,
-				AgentTypeSelect.Selected,
-				portOffset,
-				memory,
-				agentDataDir,
-				MainApp.Preferences(),
-			)
-
+			resources, err := getResources()
 			if err != nil {
-				errorDiag := dialog.NewError(err, MainWindow)
-				errorDiag.Show()
-				log.Printf("Error creating agent, with error %s\r\n", err.Error())
+				dialog.NewError(err, MainWindow).Show()
 				return
 			}
+
+			network := getNetwork()
+			if AgentTypeSelect.Selected != "Docker" {
+				// Standalone agents always use the host network.
+				network = agent.AgentNetwork{}
+			}
+
+			createAgent := func(install agent.InstallOptions) error {
+				_, err := agent.CreateNewAgent(
+					AgentNameBox.Text,
+					AgentTypeSelect.Selected,
+					portOffset,
+					memory,
+					agentDataDir,
+					agent.CreateAgentOptions{
+						Resources:     resources,
+						RestartPolicy: AgentRestartPolicyBox.Text,
+						Image:         AgentImageBox.Text,
+						Network:       network,
+						Endpoint:      AgentEndpointSelect.Selected,
+						Install:       install,
+					},
+					MainApp.Preferences(),
+				)
+				return err
+			}
+
+			if PreviewCheck.Checked {
+				ShowPreviewDialog("Create Agent", func() error {
+					return createAgent(agent.InstallOptions{NoPull: NoPullCheck.Checked})
+				})
+				return
+			}
+
+			runWithPullProgress("Creating Agent", func(onProgress func(agent.PullProgress)) error {
+				return createAgent(agent.InstallOptions{
+					NoPull:         NoPullCheck.Checked,
+					OnPullProgress: onProgress,
+				})
+			})
 		}
 	}, MainWindow)
 
@@ -228,6 +586,110 @@ func OpenCreateAgentDialog() {
 	newDialog.Show()
 }
 
+func OpenDeleteAgentDialog(agentName string) {
+	const (
+		deleteAll       = "Delete agent and SSM Cloud server"
+		deleteKeepCloud = "Delete agent, keep SSM Cloud server"
+		deleteCloudOnly = "Only delete SSM Cloud server"
+	)
+
+	DeleteOptionRadio := widget.NewRadioGroup([]string{
+		deleteAll,
+		deleteKeepCloud,
+		deleteCloudOnly,
+	}, func(string) {})
+	DeleteOptionRadio.SetSelected(deleteAll)
+	DeleteOptionRadio.Required = true
+
+	KeepDataCheck := widget.NewCheck("Keep agent data", func(bool) {})
+
+	PreviewCheck := widget.NewCheck("Preview only (dry run)", func(bool) {})
+
+	content := container.New(layout.NewVBoxLayout(),
+		widget.NewLabel("Are you sure you want to delete agent "+agentName+"?"),
+		DeleteOptionRadio,
+		KeepDataCheck,
+		PreviewCheck,
+	)
+
+	dialog.NewCustomConfirm("Delete Agent", "Delete", "Cancel", content, func(t bool) {
+		if !t {
+			return
+		}
+
+		opts := agent.DeleteAgentOptions{
+			KeepCloud: DeleteOptionRadio.Selected == deleteKeepCloud,
+			CloudOnly: DeleteOptionRadio.Selected == deleteCloudOnly,
+			KeepData:  KeepDataCheck.Checked,
+		}
+
+		if PreviewCheck.Checked {
+			ShowPreviewDialog("Delete Agent", func() error {
+				return agent.DeleteAgent(agentName, opts, MainApp.Preferences())
+			})
+			return
+		}
+
+		runAgentActionInBackground(func() error {
+			return agent.DeleteAgent(agentName, opts, MainApp.Preferences())
+		})
+	}, MainWindow).Show()
+}
+
+// runWithPullProgress runs fn in the background, showing the docker image
+// pull progress while it runs. The tabs are not refreshed until it is done.
+func runWithPullProgress(title string, fn func(onProgress func(agent.PullProgress)) error) {
+	ProgressLabel := widget.NewLabel("Working...")
+	ProgressBar := widget.NewProgressBar()
+
+	content := container.New(layout.NewVBoxLayout(), ProgressLabel, ProgressBar)
+	progressDialog := dialog.NewCustom(title, "Hide", content, MainWindow)
+	progressDialog.Resize(fyne.NewSize(400, 150))
+	progressDialog.Show()
+
+	tracker := agent.NewPullProgressTracker()
+	onProgress := func(progress agent.PullProgress) {
+		tracker.Update(progress)
+
+		fraction, complete, layers := tracker.Progress()
+		ProgressBar.SetValue(fraction)
+		ProgressLabel.SetText(fmt.Sprintf("Pulling docker image, %d of %d layers complete", complete, layers))
+	}
+
+	go func() {
+		AgentActionLock.Lock()
+		err := fn(onProgress)
+		AgentActionLock.Unlock()
+
+		progressDialog.Hide()
+		runAgentAction(err)
+	}()
+}
+
+// ShowPreviewDialog runs fn in dry run mode and lists the actions it would
+// have taken. Dry run mode is global, so no preview is made while an agent
+// action runs in the background, it would be switched to dry run as well.
+func ShowPreviewDialog(title string, fn func() error) {
+	if !AgentActionLock.TryLock() {
+		dialog.NewInformation(title+" (dry run)", "An agent action is still running, try the preview again when it has finished.", MainWindow).Show()
+		return
+	}
+
+	actions, err := agent.PreviewChanges(fn)
+	AgentActionLock.Unlock()
+
+	message := "No changes would be made."
+	if len(actions) > 0 {
+		message = "The following actions would be taken:\n\n" + strings.Join(actions, "\n")
+	}
+
+	if err != nil {
+		message += "\n\nThe operation would fail with error: " + err.Error()
+	}
+
+	dialog.NewInformation(title+" (dry run)", message, MainWindow).Show()
+}
+
 func BuildTopBar() *fyne.Container {
 
 	title := canvas.NewText("SSM Agent Manager", theme.PrimaryColor())
@@ -248,6 +710,47 @@ func BuildTopBar() *fyne.Container {
 	return topBar
 }
 
+// OpenDockerImageSettingsDialog edits the default agent image and the docker
+// registry credentials used to pull it.
+func OpenDockerImageSettingsDialog() {
+	prefs := MainApp.Preferences()
+
+	DockerImageBox := widget.NewEntry()
+	DockerImageBox.SetText(agent.GetDefaultAgentImage(prefs))
+	DockerImageBox.Validator = agent.ValidateImageReference
+
+	RegistryUsernameBox := widget.NewEntry()
+	RegistryUsernameBox.SetPlaceHolder("No registry login")
+	RegistryUsernameBox.SetText(prefs.String("dockerregistryusername"))
+
+	RegistryPasswordBox := widget.NewPasswordEntry()
+	RegistryPasswordBox.SetText(prefs.String("dockerregistrypassword"))
+
+	formItems := []*widget.FormItem{
+		{Text: "Default Agent Image:", Widget: DockerImageBox},
+		{Text: "Registry Username:", Widget: RegistryUsernameBox},
+		{Text: "Registry Password:", Widget: RegistryPasswordBox},
+	}
+
+	newDialog := dialog.NewForm("Docker Image Settings", "Save", "Cancel", formItems, func(t bool) {
+		if !t {
+			return
+		}
+
+		err := agent.SetDefaultAgentImage(prefs, DockerImageBox.Text)
+		if err != nil {
+			dialog.ShowError(err, MainWindow)
+			return
+		}
+
+		prefs.SetString("dockerregistryusername", RegistryUsernameBox.Text)
+		prefs.SetString("dockerregistrypassword", RegistryPasswordBox.Text)
+	}, MainWindow)
+
+	newDialog.Resize(fyne.NewSize(600, 250))
+	newDialog.Show()
+}
+
 func BuildHomeTabContent() *fyne.Container {
 
 	var testBtn *widget.Button
@@ -255,7 +758,7 @@ func BuildHomeTabContent() *fyne.Container {
 	SSMURLBox := widget.NewEntry()
 	SSMURLBox.PlaceHolder = "https://ssmcloud.hostxtra.co.uk"
 	SSMURLBox.Validator = emptyValidator
-	SSMURLBox.Text = MainApp.Preferences().StringWithFallback("ssmurl", "https://ssmcloud.hostxtra.co.uk")
+	SSMURLBox.Text = utils.GetSSMURL(MainApp.Preferences())
 
 	SSMAPIKeyBox := widget.NewEntry()
 	SSMAPIKeyBox.PlaceHolder = "API-XXXXXXXXXXXXX"
@@ -310,7 +813,10 @@ func BuildHomeTabContent() *fyne.Container {
 		testBtn.Enable()
 	}
 
-	buttonBox := container.New(mylayout.NewBlankLayout(), testBtn)
+	registryBtn := widget.NewButtonWithIcon("Docker Image Settings", theme.SettingsIcon(), OpenDockerImageSettingsDialog)
+	registryBtn.Move(fyne.NewPos(220, 330))
+
+	buttonBox := container.New(mylayout.NewBlankLayout(), testBtn, registryBtn)
 
 	formBox := container.New(mylayout.NewFullWidthLayout(), connectionDetailsText, seperator, form, seperator2, testText, buttonBox)
 
@@ -12,6 +12,10 @@ import (
 	"fyne.io/fyne/v2"
 )
 
+// DefaultSSMURL is the SSM Cloud URL used when the manager has none
+// configured.
+const DefaultSSMURL = "https://ssmcloud.hostxtra.co.uk"
+
 var (
 	_client *http.Client
 	baseURL string
@@ -32,12 +36,17 @@ type HttpResponseBody struct {
 	Data    interface{} `json:"data"`
 }
 
+// GetSSMURL returns the configured SSM Cloud URL, or the default one.
+func GetSSMURL(prefs fyne.Preferences) string {
+	return prefs.StringWithFallback("ssmurl", DefaultSSMURL)
+}
+
 func GetApiClient(prefs fyne.Preferences) *http.Client {
 	if _client == nil {
 		_client = http.DefaultClient
 	}
 
-	baseURL = prefs.StringWithFallback("ssmurl", "https://ssmcloud.hostxtra.co.uk")
+	baseURL = GetSSMURL(prefs)
 	apiKey = prefs.String("ssmapikey")
 
 	return _client
@@ -91,6 +100,10 @@ func SendPostRequest(prefs fyne.Preferences, endpoint string, bodyModel interfac
 
 	url := baseURL + endpoint
 
+	if SkipForDryRun("POST %s", url) {
+		return nil
+	}
+
 	fmt.Printf("#### POST #### url: %s, data: %s\r\n", url, bytes.NewBuffer(bodyJSON))
 
 	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(bodyJSON))
@@ -128,6 +141,98 @@ func SendPostRequest(prefs fyne.Preferences, endpoint string, bodyModel interfac
 	return nil
 }
 
+func SendPutRequest(prefs fyne.Preferences, endpoint string, bodyModel interface{}, returnModel interface{}) error {
+
+	GetApiClient(prefs)
+
+	bodyJSON, err := json.Marshal(bodyModel)
+
+	if err != nil {
+		return err
+	}
+
+	url := baseURL + endpoint
+
+	if SkipForDryRun("PUT %s", url) {
+		return nil
+	}
+
+	fmt.Printf("#### PUT #### url: %s, data: %s\r\n", url, bytes.NewBuffer(bodyJSON))
+
+	req, _ := http.NewRequest("PUT", url, bytes.NewBuffer(bodyJSON))
+	req.Header.Set("x-ssm-key", apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	r, err := _client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	if r.StatusCode != http.StatusOK {
+		return &APIError{ResponseCode: r.StatusCode}
+	}
+
+	defer r.Body.Close()
+
+	responseObject := HttpResponseBody{}
+
+	json.NewDecoder(r.Body).Decode(&responseObject)
+
+	if !responseObject.Success {
+		return errors.New("api returned an error: " + responseObject.Error)
+	}
+
+	b, _ := json.Marshal(responseObject.Data)
+	err = json.Unmarshal(b, returnModel)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func SendDeleteRequest(prefs fyne.Preferences, endpoint string, returnModel interface{}) error {
+
+	GetApiClient(prefs)
+
+	url := baseURL + endpoint
+
+	if SkipForDryRun("DELETE %s", url) {
+		return nil
+	}
+
+	fmt.Printf("#### DELETE #### url: %s\r\n", url)
+
+	req, _ := http.NewRequest("DELETE", url, nil)
+	req.Header.Set("x-ssm-key", apiKey)
+
+	r, err := _client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	if r.StatusCode != http.StatusOK {
+		return &APIError{ResponseCode: r.StatusCode}
+	}
+	defer r.Body.Close()
+
+	responseObject := HttpResponseBody{}
+
+	json.NewDecoder(r.Body).Decode(&responseObject)
+
+	if !responseObject.Success {
+		return errors.New("api returned an error: " + responseObject.Error)
+	}
+
+	b, _ := json.Marshal(responseObject.Data)
+	json.Unmarshal(b, returnModel)
+
+	return nil
+}
+
 func TestAPIConnection(prefs fyne.Preferences) error {
 	var resModel interface{}
 	return SendGetRequest(prefs, "/api/v1/account", &resModel)
@@ -136,6 +241,10 @@ func TestAPIConnection(prefs fyne.Preferences) error {
 func DownloadFile(prefs fyne.Preferences, url string, filePath string) error {
 	GetApiClient(prefs)
 
+	if SkipForDryRun("download %s to %s", url, filePath) {
+		return nil
+	}
+
 	fmt.Printf("#### DOWNLOAD #### url: %s\r\n", url)
 
 	req, _ := http.NewRequest("GET", url, nil)
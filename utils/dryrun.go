@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+)
+
+var (
+	dryRun         bool
+	plannedActions []string
+)
+
+// SetDryRun turns dry run mode on or off and clears the planned actions.
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+	plannedActions = nil
+}
+
+func IsDryRun() bool {
+	return dryRun
+}
+
+// SkipForDryRun records a side effect that is about to happen. It returns
+// true in dry run mode, in which case the caller must skip the side effect.
+func SkipForDryRun(format string, a ...interface{}) bool {
+	if !dryRun {
+		return false
+	}
+
+	action := fmt.Sprintf(format, a...)
+	if len(plannedActions) == 0 || plannedActions[len(plannedActions)-1] != action {
+		plannedActions = append(plannedActions, action)
+	}
+	return true
+}
+
+// GetPlannedActions returns the side effects that were skipped in dry run
+// mode, in the order they would have happened.
+func GetPlannedActions() []string {
+	return append([]string{}, plannedActions...)
+}
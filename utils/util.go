@@ -1,12 +1,19 @@
 package utils
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 )
 
 func CreateFolder(folderPath string) error {
 	if _, err := os.Stat(folderPath); errors.Is(err, os.ErrNotExist) {
+		if SkipForDryRun("create directory %s", folderPath) {
+			return nil
+		}
+
 		err := os.MkdirAll(folderPath, os.ModePerm)
 		if err != nil {
 			return err
@@ -15,7 +22,54 @@ func CreateFolder(folderPath string) error {
 	return nil
 }
 
+// RemoveAll removes a file or directory and everything in it.
+func RemoveAll(path string) error {
+	if !CheckFileExists(path) {
+		return nil
+	}
+
+	if SkipForDryRun("remove %s", path) {
+		return nil
+	}
+	return os.RemoveAll(path)
+}
+
+func RemoveFile(filePath string) error {
+	if SkipForDryRun("remove file %s", filePath) {
+		return nil
+	}
+	return os.Remove(filePath)
+}
+
+func WriteFile(filePath string, data []byte, perm os.FileMode) error {
+	if SkipForDryRun("write file %s", filePath) {
+		return nil
+	}
+	return os.WriteFile(filePath, data, perm)
+}
+
+func MovePath(oldPath string, newPath string) error {
+	if SkipForDryRun("move %s to %s", oldPath, newPath) {
+		return nil
+	}
+	return os.Rename(oldPath, newPath)
+}
+
 func CheckFileExists(filepath string) bool {
 	_, err := os.Stat(filepath)
 	return !os.IsNotExist(err)
 }
+
+// AskForConfirmation asks a yes or no question on the terminal. Anything
+// other than yes is treated as no.
+func AskForConfirmation(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
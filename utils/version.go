@@ -0,0 +1,5 @@
+package utils
+
+// Version is the agent manager version. It is a variable so release builds
+// can set it with -ldflags "-X".
+var Version = "v0.0.1"